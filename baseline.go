@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+
+	"gosecurityheaders/pkg/scanner"
+)
+
+// baseline holds the header results of a previously accepted scan, keyed
+// by URL and header name, whose findings are not reported again
+type baseline map[string]map[string]scanner.HeaderResult
+
+// loadBaseline loads a baseline from the JSON output of a previous scan. A
+// missing file is an empty baseline so that --update-baseline can create it.
+func loadBaseline(filePath string) (baseline, error) {
+	previous, err := loadReport(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return baseline{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b := make(baseline)
+	for _, result := range previous.Results {
+		headers := make(map[string]scanner.HeaderResult)
+		for _, header := range result.Headers {
+			headers[header.Name] = header
+		}
+		b[result.URL] = headers
+	}
+	return b, nil
+}
+
+// writeBaseline records the results as the new baseline
+func writeBaseline(filePath string, results []scanner.Result) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return writeJSON(file, results)
+}
+
+// filter drops the findings of the result that the baseline already
+// recorded, so only regressions are reported. Headers whose status changed
+// keep all their findings, and the grade still reflects the whole scan.
+func (b baseline) filter(result scanner.Result) scanner.Result {
+	known, ok := b[result.URL]
+	if !ok {
+		return result
+	}
+
+	var headers []scanner.HeaderResult
+	for _, header := range result.Headers {
+		previous, ok := known[header.Name]
+		if !ok || previous.Status != header.Status {
+			headers = append(headers, header)
+			continue
+		}
+		header.Issues = newFindings(header.Issues, previous.Issues)
+		header.Warnings = newFindings(header.Warnings, previous.Warnings)
+		header.Severity = scanner.SeverityFor(header)
+		if header.Status == scanner.StatusPresent || len(header.Issues) > 0 || len(header.Warnings) > 0 {
+			headers = append(headers, header)
+		}
+	}
+	result.Headers = headers
+	return result
+}
+
+// newFindings returns the findings that are not in the previous ones
+func newFindings(findings, previous []string) []string {
+	known := make(map[string]bool)
+	for _, finding := range previous {
+		known[finding] = true
+	}
+	var added []string
+	for _, finding := range findings {
+		if !known[finding] {
+			added = append(added, finding)
+		}
+	}
+	return added
+}
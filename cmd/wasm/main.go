@@ -0,0 +1,101 @@
+//go:build js && wasm
+
+// Command wasm exposes the checks of pkg/scanner to JavaScript, for browser
+// extensions and web UIs. Built with
+//
+//	GOOS=js GOARCH=wasm go build -o gosecurityheaders.wasm ./cmd/wasm
+//
+// and run with the wasm_exec.js of the Go distribution, it sets a global
+// gosecurityheaders object whose functions return promises of a result in
+// the format of the JSON output:
+//
+//	// Checks a response fetched by the caller
+//	gosecurityheaders.check(JSON.stringify({url, status_code, protocol, headers, body}))
+//	// Fetches and checks a URL with the fetch API, as CORS allows
+//	gosecurityheaders.scan("https://example.com")
+//
+// The headers map each name to its values, and the optional body is base64
+// encoded, like the responses given to --plugin programs.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"syscall/js"
+
+	"gosecurityheaders/pkg/scanner"
+)
+
+// response is the response given to check
+type response struct {
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Protocol   string      `json:"protocol"`
+	Headers    http.Header `json:"headers"`
+	Body       []byte      `json:"body"`
+}
+
+func main() {
+	s := scanner.New(scanner.Options{})
+	js.Global().Set("gosecurityheaders", map[string]any{
+		"check": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			return promise(func() (scanner.Result, error) {
+				if len(args) == 0 {
+					return scanner.Result{}, errors.New("check expects a response")
+				}
+				var r response
+				if err := json.Unmarshal([]byte(args[0].String()), &r); err != nil {
+					return scanner.Result{}, err
+				}
+				// Canonicalize the names, as the fetch API lowercases them
+				headers := http.Header{}
+				for name, values := range r.Headers {
+					for _, value := range values {
+						headers.Add(name, value)
+					}
+				}
+				return s.CheckResponse(context.Background(), scanner.Response{URL: r.URL, StatusCode: r.StatusCode, Protocol: r.Protocol, Header: headers, Body: r.Body})
+			})
+		}),
+		"scan": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			return promise(func() (scanner.Result, error) {
+				if len(args) == 0 {
+					return scanner.Result{}, errors.New("scan expects a URL")
+				}
+				results, err := s.Scan(context.Background(), []string{args[0].String()})
+				if err != nil {
+					return scanner.Result{}, err
+				}
+				if len(results) == 0 {
+					return scanner.Result{}, errors.New("skipped " + args[0].String())
+				}
+				return results[0], nil
+			})
+		}),
+	})
+	select {}
+}
+
+// promise runs fn in the background, since requests block until the event
+// loop gets to them, and returns a promise of its result as JSON
+func promise(fn func() (scanner.Result, error)) js.Value {
+	executor := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		resolve, reject := args[0], args[1]
+		go func() {
+			result, err := fn()
+			if err == nil {
+				var output []byte
+				if output, err = json.Marshal(result); err == nil {
+					resolve.Invoke(string(output))
+					return
+				}
+			}
+			reject.Invoke(js.Global().Get("Error").New(err.Error()))
+		}()
+		return nil
+	})
+	defer executor.Release()
+	return js.Global().Get("Promise").New(executor)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"gosecurityheaders/pkg/scanner"
+)
+
+// defaultConfigFile is the config file read from the home directory when
+// --config is not given
+const defaultConfigFile = ".gosecurityheaders.yaml"
+
+// envPrefix prefixes the environment variables that set flags, e.g.
+// GSH_SKIP_SSL for --skip-ssl
+const envPrefix = "GSH_"
+
+// envName returns the environment variable that sets a flag
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// loadEnv sets every flag not set on the command line from its environment
+// variable, if defined
+func loadEnv() error {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	var err error
+	flag.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok || set[f.Name] || err != nil {
+			return
+		}
+		if setErr := flag.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("%s: %v", envName(f.Name), setErr)
+		}
+	})
+	return err
+}
+
+// configPath returns the config file to load: the --config file, or the
+// default file in the home directory when it exists
+func configPath(explicit string) string {
+	if explicit != "" {
+		return explicit
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(home, defaultConfigFile)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// loadConfig applies a YAML or JSON config file mapping flag names to
+// values, for every flag not set on the command line, and returns the
+// targets it lists. Targets are written like the lines of an --input file,
+// a URL followed by its tags, or as mappings that may set credentials, and
+// lists are joined with commas.
+func loadConfig(filePath string) ([]scanner.Target, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var config map[string]interface{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	var targets []scanner.Target
+	for name, value := range config {
+		if name == "targets" {
+			lines, ok := value.([]interface{})
+			if !ok {
+				return nil, errors.New("targets must be a list")
+			}
+			for _, line := range lines {
+				t, err := configTarget(line)
+				if err != nil {
+					return nil, fmt.Errorf("targets: %v", err)
+				}
+				targets = append(targets, t)
+			}
+			continue
+		}
+
+		if flag.Lookup(name) == nil {
+			return nil, fmt.Errorf("unknown option %q", name)
+		}
+		if set[name] || name == "config" {
+			continue
+		}
+		if err := setConfigFlag(name, value); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return targets, nil
+}
+
+// setConfigFlag sets a flag from a config value. Lists set flags that can
+// be repeated once per item, and other flags to the comma-joined items.
+func setConfigFlag(name string, value interface{}) error {
+	items, isList := value.([]interface{})
+	if _, repeatable := flag.Lookup(name).Value.(scanner.RepeatableFlag); !isList || !repeatable {
+		return flag.Set(name, configValue(value))
+	}
+	for _, item := range items {
+		if err := flag.Set(name, fmt.Sprint(item)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// configValue formats a config value as it would be given on the command
+// line
+func configValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// configTarget parses a target of a config file, either a line like those
+// of an --input file or a mapping that may also set the target's
+// credentials:
+//
+//	url: https://example.com/account
+//	tags: [sensitive]
+//	auth-bearer: TOKEN
+func configTarget(item interface{}) (scanner.Target, error) {
+	fields, ok := item.(map[string]interface{})
+	if !ok {
+		line := strings.Fields(fmt.Sprint(item))
+		if len(line) == 0 {
+			return scanner.Target{}, errors.New("empty target")
+		}
+		return scanner.Target{URL: line[0], Tags: line[1:]}, nil
+	}
+
+	var t scanner.Target
+	for key, value := range fields {
+		switch key {
+		case "url":
+			t.URL = fmt.Sprint(value)
+		case "tags":
+			t.Tags = scanner.SplitList(configValue(value))
+		case "auth-basic":
+			t.Auth.Basic = fmt.Sprint(value)
+		case "auth-bearer":
+			t.Auth.Bearer = fmt.Sprint(value)
+		case "header":
+			headers, ok := value.([]interface{})
+			if !ok {
+				headers = []interface{}{value}
+			}
+			for _, header := range headers {
+				t.Auth.Headers = append(t.Auth.Headers, fmt.Sprint(header))
+			}
+		default:
+			return scanner.Target{}, fmt.Errorf("unknown target option %q", key)
+		}
+	}
+	if t.URL == "" {
+		return scanner.Target{}, errors.New("target has no url")
+	}
+	if err := t.Auth.Validate(); err != nil {
+		return scanner.Target{}, fmt.Errorf("%s: %v", t.URL, err)
+	}
+	return t, nil
+}
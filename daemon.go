@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"gosecurityheaders/pkg/scanner"
+)
+
+// daemonStatus is the response of GET /status of the daemon subcommand
+type daemonStatus struct {
+	SchemaVersion string `json:"schema_version"`
+	// State is scanning or waiting
+	State    string `json:"state"`
+	Interval string `json:"interval"`
+	Targets  int    `json:"targets"`
+	// Rounds counts the finished scans of the targets
+	Rounds       int        `json:"rounds"`
+	LastStarted  *time.Time `json:"last_started,omitempty"`
+	LastFinished *time.Time `json:"last_finished,omitempty"`
+	NextScan     *time.Time `json:"next_scan,omitempty"`
+	// Grades maps the URLs of the last round to their grades
+	Grades map[string]string `json:"grades"`
+	Errors []restError       `json:"errors"`
+}
+
+// daemon rescans its targets on an interval, keeping the results of the
+// last finished round
+type daemon struct {
+	targets     []scanner.Target
+	interval    time.Duration
+	concurrency int
+	score       func(*http.Response, []scanner.HeaderResult) (int, string)
+	// filter is applied to each result before it is kept, e.g. waiving
+	// the accepted risks
+	filter func(scanner.Result) scanner.Result
+	// export writes the results of each round, e.g. to --output
+	export func([]scanner.Result) error
+
+	mu       sync.Mutex
+	scanning bool
+	rounds   int
+	started  time.Time
+	finished time.Time
+	next     time.Time
+	results  []scanner.Result
+	errors   []restError
+}
+
+// run scans the targets every interval until ctx is done, serving their
+// status and latest results on an address
+func (d *daemon) run(ctx context.Context, address string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	server := &http.Server{Addr: address, Handler: d.handler(), ReadHeaderTimeout: 10 * time.Second}
+	var serveErr error
+	served := make(chan struct{})
+	go func() {
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			serveErr = err
+			cancel()
+		}
+		close(served)
+	}()
+
+	d.schedule(ctx)
+	shutdown, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelShutdown()
+	server.Shutdown(shutdown)
+	<-served
+	return serveErr
+}
+
+// schedule scans the targets every interval, counted from the start of
+// each round, until ctx is done
+func (d *daemon) schedule(ctx context.Context) {
+	for {
+		started := time.Now()
+		d.round(ctx, started)
+		timer := time.NewTimer(time.Until(started.Add(d.interval)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// round scans the targets once, replacing the kept results when it
+// finishes. A round cut short by ctx keeps the previous results.
+func (d *daemon) round(ctx context.Context, started time.Time) {
+	d.mu.Lock()
+	d.scanning, d.started = true, started
+	d.mu.Unlock()
+
+	results := []scanner.Result{}
+	errs := []restError{}
+	for i, outcome := range scanner.ScanTargets(ctx, d.targets, d.concurrency, d.score) {
+		url := d.targets[i].URL
+		scan := <-outcome
+		switch {
+		case scan.Err != nil:
+			log.Printf("Error fetching headers for %s: %v\n", url, scan.Err)
+			errs = append(errs, restError{URL: url, Error: scan.Err.Error()})
+		case scan.Skip != "":
+			log.Printf("Skipping %s: %s\n", url, scan.Skip)
+			errs = append(errs, restError{URL: url, Error: scan.Skip, Skipped: true})
+		default:
+			results = append(results, d.filter(scan.Result))
+		}
+	}
+
+	d.mu.Lock()
+	d.scanning = false
+	if ctx.Err() != nil {
+		d.mu.Unlock()
+		return
+	}
+	d.rounds++
+	d.finished, d.next = time.Now(), started.Add(d.interval)
+	d.results, d.errors = results, errs
+	d.mu.Unlock()
+
+	log.Printf("Scanned %d of %d URLs, next scan at %s\n", len(results), len(d.targets), started.Add(d.interval).Format(time.RFC3339))
+	if d.export != nil {
+		if err := d.export(results); err != nil {
+			log.Printf("Error exporting the results: %v\n", err)
+		}
+	}
+}
+
+// status returns the state of the daemon and its last round
+func (d *daemon) status() daemonStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	status := daemonStatus{
+		SchemaVersion: scanner.SchemaVersion,
+		State:         "waiting",
+		Interval:      d.interval.String(),
+		Targets:       len(d.targets),
+		Rounds:        d.rounds,
+		Grades:        make(map[string]string),
+		Errors:        append([]restError{}, d.errors...),
+	}
+	if d.scanning {
+		status.State = "scanning"
+	}
+	if !d.started.IsZero() {
+		started := d.started
+		status.LastStarted = &started
+	}
+	if d.rounds > 0 {
+		finished, next := d.finished, d.next
+		status.LastFinished, status.NextScan = &finished, &next
+	}
+	for _, result := range d.results {
+		status.Grades[result.URL] = result.Grade
+	}
+	return status
+}
+
+// handler returns the HTTP API of the daemon: GET /status and GET /results,
+// the results of the last round in the format of the JSON output
+func (d *daemon) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		writeREST(w, http.StatusOK, d.status())
+	})
+	mux.HandleFunc("GET /results", func(w http.ResponseWriter, r *http.Request) {
+		d.mu.Lock()
+		results := d.results
+		d.mu.Unlock()
+		if results == nil {
+			writeRESTError(w, http.StatusServiceUnavailable, "the first scan has not finished yet")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		writeJSON(w, results)
+	})
+	return mux
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"gosecurityheaders/pkg/scanner"
+)
+
+// findingChange is a finding of one URL that the newer scan added, removed
+// or changed. Added findings only have New, removed ones only Old.
+type findingChange struct {
+	URL  string                `json:"url"`
+	Name string                `json:"name"`
+	Old  *scanner.HeaderResult `json:"old,omitempty"`
+	New  *scanner.HeaderResult `json:"new,omitempty"`
+}
+
+// gradeChange is a URL whose grade differs between the scans
+type gradeChange struct {
+	URL string `json:"url"`
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// resultDiff is the output of the diff subcommand
+type resultDiff struct {
+	SchemaVersion string          `json:"schema_version"`
+	Added         []findingChange `json:"added"`
+	Removed       []findingChange `json:"removed"`
+	Changed       []findingChange `json:"changed"`
+	Grades        []gradeChange   `json:"grades"`
+}
+
+// isFinding reports whether a header result is a finding, i.e. anything
+// other than a header present without issues or warnings
+func isFinding(header scanner.HeaderResult) bool {
+	return header.Status != scanner.StatusPresent || len(header.Issues) > 0 || len(header.Warnings) > 0
+}
+
+// diffReports compares the results of two scans by URL and header name.
+// URLs scanned only once count all of their findings as added or removed.
+func diffReports(old, new report) resultDiff {
+	d := resultDiff{
+		SchemaVersion: scanner.SchemaVersion,
+		Added:         []findingChange{},
+		Removed:       []findingChange{},
+		Changed:       []findingChange{},
+		Grades:        []gradeChange{},
+	}
+	before := make(map[string]scanner.Result)
+	for _, result := range old.Results {
+		before[result.URL] = result
+	}
+	after := make(map[string]bool)
+	for _, result := range new.Results {
+		after[result.URL] = true
+		previous, ok := before[result.URL]
+		if ok && previous.Grade != result.Grade {
+			d.Grades = append(d.Grades, gradeChange{URL: result.URL, Old: previous.Grade, New: result.Grade})
+		}
+		d.compare(result.URL, previous.Headers, result.Headers)
+	}
+	for _, result := range old.Results {
+		if !after[result.URL] {
+			d.compare(result.URL, result.Headers, nil)
+		}
+	}
+	return d
+}
+
+// compare adds the differences between the header results of a URL
+func (d *resultDiff) compare(url string, old, new []scanner.HeaderResult) {
+	before := make(map[string]scanner.HeaderResult)
+	for _, header := range old {
+		if _, ok := before[header.Name]; !ok {
+			before[header.Name] = header
+		}
+	}
+	compared := make(map[string]bool)
+	for _, header := range new {
+		if compared[header.Name] {
+			continue
+		}
+		compared[header.Name] = true
+		previous, ok := before[header.Name]
+		wasFinding := ok && isFinding(previous)
+		switch {
+		case isFinding(header) && !wasFinding:
+			d.Added = append(d.Added, findingChange{URL: url, Name: header.Name, New: &header})
+		case !isFinding(header) && wasFinding:
+			d.Removed = append(d.Removed, findingChange{URL: url, Name: header.Name, Old: &previous})
+		case wasFinding && findingsDiffer(previous, header):
+			d.Changed = append(d.Changed, findingChange{URL: url, Name: header.Name, Old: &previous, New: &header})
+		}
+	}
+	for _, header := range old {
+		if !compared[header.Name] && isFinding(header) {
+			compared[header.Name] = true
+			d.Removed = append(d.Removed, findingChange{URL: url, Name: header.Name, Old: &header})
+		}
+	}
+}
+
+// findingsDiffer reports whether the status, severity, issues or warnings
+// of a finding changed
+func findingsDiffer(old, new scanner.HeaderResult) bool {
+	return old.Status != new.Status || old.Severity != new.Severity ||
+		!slices.Equal(old.Issues, new.Issues) || !slices.Equal(old.Warnings, new.Warnings)
+}
+
+// meetsSeverity reports whether an added or changed finding is at least as
+// severe as the threshold
+func (d resultDiff) meetsSeverity(threshold scanner.Severity) bool {
+	var findings []scanner.HeaderResult
+	for _, change := range append(append([]findingChange(nil), d.Added...), d.Changed...) {
+		findings = append(findings, *change.New)
+	}
+	return scanner.MeetsSeverity(findings, threshold)
+}
+
+// loadReport reads the JSON output of a scan
+func loadReport(filePath string) (report, error) {
+	var r report
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return r, err
+	}
+	if r.SchemaVersion != "" && r.SchemaVersion != scanner.SchemaVersion {
+		return r, fmt.Errorf("schema version %s is not supported, expected %s", r.SchemaVersion, scanner.SchemaVersion)
+	}
+	return r, nil
+}
+
+// exportDiff writes the differences as text or JSON to a file, or to
+// stdout without one
+func exportDiff(filePath, format string, d resultDiff) error {
+	write := writeDiffText
+	switch format {
+	case "", "text":
+	case "json":
+		write = writeDiffJSON
+	default:
+		return fmt.Errorf("the diff subcommand writes text or json, not %s", format)
+	}
+	if filePath == "" {
+		return write(os.Stdout, d)
+	}
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if err := write(file, d); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writeDiffJSON writes the differences as indented JSON
+func writeDiffJSON(w io.Writer, d resultDiff) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(d)
+}
+
+// writeDiffText writes the differences for reading, e.g. in the log of a
+// release pipeline
+func writeDiffText(w io.Writer, d resultDiff) error {
+	var b strings.Builder
+	if len(d.Added)+len(d.Removed)+len(d.Changed)+len(d.Grades) == 0 {
+		b.WriteString("No findings changed\n")
+	}
+	if len(d.Grades) > 0 {
+		b.WriteString("Grades:\n")
+		for _, grade := range d.Grades {
+			fmt.Fprintf(&b, "  %s: %s -> %s\n", grade.URL, grade.Old, grade.New)
+		}
+	}
+	if len(d.Added) > 0 {
+		b.WriteString("Added findings:\n")
+		for _, change := range d.Added {
+			fmt.Fprintf(&b, "  + %s %s: %s\n", change.URL, change.Name, findingSummary(*change.New))
+			writeFindingLines(&b, "+", change.New.Issues, change.New.Warnings)
+		}
+	}
+	if len(d.Removed) > 0 {
+		b.WriteString("Removed findings:\n")
+		for _, change := range d.Removed {
+			fmt.Fprintf(&b, "  - %s %s: %s\n", change.URL, change.Name, findingSummary(*change.Old))
+		}
+	}
+	if len(d.Changed) > 0 {
+		b.WriteString("Changed findings:\n")
+		for _, change := range d.Changed {
+			fmt.Fprintf(&b, "  ~ %s %s: %s -> %s\n", change.URL, change.Name, findingSummary(*change.Old), findingSummary(*change.New))
+			writeFindingLines(&b, "+", newFindings(change.New.Issues, change.Old.Issues), newFindings(change.New.Warnings, change.Old.Warnings))
+			writeFindingLines(&b, "-", newFindings(change.Old.Issues, change.New.Issues), newFindings(change.Old.Warnings, change.New.Warnings))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// findingSummary describes the status and severity of a finding
+func findingSummary(header scanner.HeaderResult) string {
+	if header.Severity == "" {
+		return string(header.Status)
+	}
+	return fmt.Sprintf("%s [%s]", header.Status, header.Severity)
+}
+
+// writeFindingLines lists issues and warnings under a finding, marked as
+// added (+) or resolved (-)
+func writeFindingLines(b *strings.Builder, mark string, issues, warnings []string) {
+	for _, issue := range issues {
+		fmt.Fprintf(b, "      %s %s\n", mark, issue)
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(b, "      %s warning: %s\n", mark, warning)
+	}
+}
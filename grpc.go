@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"net"
+
+	"gosecurityheaders/pkg/scanner"
+	"gosecurityheaders/pkg/scannerpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcServer implements the Scanner service of pkg/scannerpb/scanner.proto
+type grpcServer struct {
+	scannerpb.UnimplementedScannerServer
+	jobs *jobStore
+}
+
+func (s *grpcServer) Scan(_ context.Context, req *scannerpb.ScanRequest) (*scannerpb.ScanResponse, error) {
+	if len(req.Urls) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no urls to scan")
+	}
+	job := s.jobs.start(req.Urls)
+	return &scannerpb.ScanResponse{Id: job.ID}, nil
+}
+
+func (s *grpcServer) GetResult(_ context.Context, req *scannerpb.GetResultRequest) (*scannerpb.ScanStatus, error) {
+	job := s.jobs.get(req.Id)
+	if job == nil {
+		return nil, status.Errorf(codes.NotFound, "no scan %q", req.Id)
+	}
+	events, done, _ := job.snapshot(0)
+	scan := &scannerpb.ScanStatus{Id: job.ID, State: scannerpb.ScanStatus_STATE_RUNNING, Total: int32(job.Total)}
+	if done {
+		scan.State = scannerpb.ScanStatus_STATE_DONE
+	}
+	for _, event := range events {
+		if event.Result != nil {
+			scan.Results = append(scan.Results, grpcResult(*event.Result))
+		} else {
+			scan.Errors = append(scan.Errors, grpcError(event))
+		}
+	}
+	return scan, nil
+}
+
+func (s *grpcServer) Watch(req *scannerpb.WatchRequest, stream grpc.ServerStreamingServer[scannerpb.ScanEvent]) error {
+	job := s.jobs.get(req.Id)
+	if job == nil {
+		return status.Errorf(codes.NotFound, "no scan %q", req.Id)
+	}
+	return job.watch(stream.Context(), func(event jobEvent) error {
+		if event.Result != nil {
+			return stream.Send(&scannerpb.ScanEvent{Event: &scannerpb.ScanEvent_Result{Result: grpcResult(*event.Result)}})
+		}
+		return stream.Send(&scannerpb.ScanEvent{Event: &scannerpb.ScanEvent_Error{Error: grpcError(event)}})
+	})
+}
+
+// grpcResult converts a result to its protobuf message
+func grpcResult(r scanner.Result) *scannerpb.Result {
+	result := &scannerpb.Result{
+		Url:        r.URL,
+		Grade:      r.Grade,
+		Score:      int32(r.Score),
+		StatusCode: int32(r.StatusCode),
+		Protocol:   r.Protocol,
+	}
+	for _, header := range r.Headers {
+		result.Headers = append(result.Headers, &scannerpb.HeaderResult{
+			Name:     header.Name,
+			Present:  header.Present,
+			Status:   string(header.Status),
+			Value:    header.Value,
+			Severity: string(header.Severity),
+			Issues:   header.Issues,
+			Warnings: header.Warnings,
+			Category: header.Category,
+		})
+	}
+	return result
+}
+
+// grpcError converts a URL without a result to its protobuf message
+func grpcError(event jobEvent) *scannerpb.ScanError {
+	return &scannerpb.ScanError{Url: event.URL, Message: event.Err, Skipped: event.Skipped}
+}
+
+// serveGRPC serves the Scanner service on an address until ctx is done
+func serveGRPC(ctx context.Context, address, token string, jobs *jobStore) error {
+	warnExposed(address, token)
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	var options []grpc.ServerOption
+	if token != "" {
+		options = append(options,
+			grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				if err := checkGRPCToken(ctx, token); err != nil {
+					return nil, err
+				}
+				return handler(ctx, req)
+			}),
+			grpc.StreamInterceptor(func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				if err := checkGRPCToken(stream.Context(), token); err != nil {
+					return err
+				}
+				return handler(srv, stream)
+			}),
+		)
+	}
+	server := grpc.NewServer(options...)
+	scannerpb.RegisterScannerServer(server, &grpcServer{jobs: jobs})
+	go func() {
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
+	return server.Serve(listener)
+}
+
+// checkGRPCToken rejects calls whose authorization metadata doesn't carry
+// the bearer token
+func checkGRPCToken(ctx context.Context, token string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, authorization := range md.Get("authorization") {
+		if tokenMatches(authorization, token) {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+}
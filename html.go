@@ -0,0 +1,142 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"gosecurityheaders/pkg/scanner"
+)
+
+// htmlTemplate is the self-contained HTML report layout
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"statusClass": func(status scanner.Status) string { return strings.ToLower(string(status)) },
+	"gradeClass":  func(grade string) string { return strings.ToLower(strings.TrimRight(grade, "+-")) },
+	"lower":       func(severity scanner.Severity) string { return strings.ToLower(string(severity)) },
+	"retried":     retriedNote,
+	"response":    responseNote,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Security Headers Report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0.2em; }
+.generated { color: #666; margin-top: 0; }
+.summary td, .summary th { padding: 0.3em 1em; text-align: left; }
+section { border: 1px solid #ddd; border-radius: 4px; margin: 1.5em 0; padding: 0 1em 1em; }
+table.headers { border-collapse: collapse; width: 100%; }
+table.headers th, table.headers td { border-bottom: 1px solid #eee; padding: 0.4em; text-align: left; vertical-align: top; }
+.present { color: #1a7f37; font-weight: bold; }
+.missing { color: #cf222e; font-weight: bold; }
+.misconfigured, .report-only, .deprecated, .disclosure { color: #9a6700; font-weight: bold; }
+.waived { color: #666; }
+.severity-critical { color: #fff; background: #cf222e; font-weight: bold; }
+.severity-high { color: #cf222e; font-weight: bold; }
+.severity-medium { color: #9a6700; }
+.severity-info { color: #0969da; }
+ul.findings { margin: 0.3em 0; padding-left: 1.2em; }
+.warning { color: #9a6700; }
+.grade { display: inline-block; min-width: 1.6em; padding: 0 0.3em; border-radius: 4px; color: #fff; text-align: center; background: #cf222e; }
+.grade-a { background: #1a7f37; }
+.grade-b, .grade-c { background: #9a6700; }
+.score { color: #666; margin-top: -0.5em; }
+pre { white-space: pre-wrap; word-break: break-all; margin: 0.3em 0; }
+</style>
+</head>
+<body>
+<h1>Security Headers Report</h1>
+<p class="generated">Generated {{.Generated}}</p>
+<table class="summary">
+<tr><th>URLs scanned</th><td>{{.URLs}}</td></tr>
+<tr><th>Headers present</th><td class="present">{{.Present}}</td></tr>
+<tr><th>Headers misconfigured</th><td class="misconfigured">{{.Misconfigured}}</td></tr>
+<tr><th>Headers report-only</th><td class="report-only">{{.ReportOnly}}</td></tr>
+<tr><th>Deprecated headers</th><td class="deprecated">{{.Deprecated}}</td></tr>
+<tr><th>Information disclosure headers</th><td class="disclosure">{{.Disclosure}}</td></tr>
+<tr><th>Headers missing</th><td class="missing">{{.Missing}}</td></tr>
+<tr><th>Waived findings</th><td class="waived">{{.Waived}}</td></tr>
+</table>
+{{range .Results}}
+<section>
+<h2>{{.URL}} <span class="grade grade-{{gradeClass .Grade}}">{{.Grade}}</span></h2>
+<p class="score">Score {{.Score}}/100{{response .}}{{retried .}}</p>
+{{if or .TLS .Redirects}}<ul class="redirects">
+{{with .TLS}}<li>TLS: {{.}}</li>
+{{with .Certificate}}<li>Certificate: {{.}}</li>
+{{end}}{{end}}{{range .Redirects}}<li>Redirect: {{.}}</li>
+{{end}}</ul>{{end}}
+<table class="headers">
+<tr><th>Header</th><th>Status</th><th>Severity</th><th>Value</th></tr>
+{{range .Headers}}<tr>
+<td>{{.Name}}</td>
+<td class="{{statusClass .Status}}">{{.Status}}</td>
+<td class="severity-{{lower .Severity}}">{{.Severity}}</td>
+<td>{{if .Present}}<details><summary>Show value</summary><pre>{{.Value}}</pre></details>{{end}}
+{{if or .Issues .Warnings}}<ul class="findings">
+{{range .Issues}}<li>{{.}}</li>
+{{end}}{{range .Warnings}}<li class="warning">Warning: {{.}}</li>
+{{end}}</ul>{{end}}</td>
+</tr>
+{{end}}</table>
+{{if .Waived}}<h3>Waived findings</h3>
+<table class="headers">
+<tr><th>Header</th><th>Status</th><th>Severity</th><th>Reason</th><th>Expires</th></tr>
+{{range .Waived}}<tr>
+<td>{{.Name}}</td>
+<td class="waived">{{.Status}}</td>
+<td>{{.Severity}}</td>
+<td>{{.Reason}}</td>
+<td>{{.Expires}}</td>
+</tr>
+{{end}}</table>{{end}}
+</section>
+{{end}}
+</body>
+</html>
+`))
+
+// htmlReport holds the data rendered into the HTML report
+type htmlReport struct {
+	Generated     string
+	URLs          int
+	Present       int
+	Misconfigured int
+	ReportOnly    int
+	Deprecated    int
+	Disclosure    int
+	Missing       int
+	Waived        int
+	Results       []scanner.Result
+}
+
+// writeHTML writes the results as a self-contained HTML report
+func writeHTML(w io.Writer, results []scanner.Result) error {
+	report := htmlReport{
+		Generated: time.Now().Format(time.RFC1123),
+		URLs:      len(results),
+		Results:   results,
+	}
+	for _, result := range results {
+		report.Waived += len(result.Waived)
+		for _, header := range result.Headers {
+			switch header.Status {
+			case scanner.StatusPresent:
+				report.Present++
+			case scanner.StatusMisconfigured:
+				report.Misconfigured++
+			case scanner.StatusReportOnly:
+				report.ReportOnly++
+			case scanner.StatusDeprecated:
+				report.Deprecated++
+			case scanner.StatusDisclosure:
+				report.Disclosure++
+			default:
+				report.Missing++
+			}
+		}
+	}
+	return htmlTemplate.Execute(w, report)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gosecurityheaders/pkg/scanner"
+)
+
+// influxTag escapes a tag value for the InfluxDB line protocol
+func influxTag(s string) string {
+	return strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`).Replace(s)
+}
+
+// influxString escapes a string field value for the InfluxDB line protocol
+func influxString(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+// writeInflux writes the results in InfluxDB line protocol, with one point
+// per URL and header in the security_header measurement and one point per
+// URL in the security_grade measurement
+func writeInflux(w io.Writer, results []scanner.Result) error {
+	bw := bufio.NewWriter(w)
+	timestamp := time.Now().UnixNano()
+	for _, result := range results {
+		// Only results served over TLS have TLS fields
+		tlsFields := ""
+		if result.TLS != nil {
+			tlsFields = fmt.Sprintf(",tls_version=\"%s\",cipher_suite=\"%s\",certificate_days_left=%di,ocsp_stapled=%t", result.TLS.Version, result.TLS.CipherSuite, result.TLS.DaysLeft, result.TLS.OCSPStapled)
+		}
+		fmt.Fprintf(bw, "security_grade,url=%s score=%di,grade=\"%s\",status_code=%di,protocol=\"%s\",content_encoding=\"%s\",family=\"%s\",waived=%di,retries=%di,redirects=%di%s %d\n",
+			influxTag(result.URL), result.Score, influxString(result.Grade), result.StatusCode, influxString(result.Protocol), influxString(result.ContentEncoding), result.Family, len(result.Waived), result.Retries, len(result.Redirects), tlsFields, timestamp)
+		for _, header := range result.Headers {
+			present := 0
+			if header.Present {
+				present = 1
+			}
+			fmt.Fprintf(bw, "security_header,url=%s,header=%s present=%di,status=\"%s\",severity=\"%s\",value=\"%s\" %d\n",
+				influxTag(result.URL), influxTag(header.Name), present, header.Status, header.Severity, influxString(header.Value), timestamp)
+		}
+	}
+	return bw.Flush()
+}
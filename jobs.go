@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+
+	"gosecurityheaders/pkg/scanner"
+)
+
+// maxJobs is how many scans the servers keep, forgetting the oldest
+// finished ones beyond it
+const maxJobs = 1000
+
+// jobEvent is a URL of a scan job finishing, with either its result or
+// why it has none
+type jobEvent struct {
+	URL    string
+	Result *scanner.Result
+	Err    string
+	// Skipped is set for URLs left out by --non-2xx or robots.txt
+	Skipped bool
+}
+
+// scanJob is a scan requested from a server, which runs in the background
+// while clients poll or watch it
+type scanJob struct {
+	ID    string
+	Total int
+
+	mu     sync.Mutex
+	events []jobEvent
+	done   bool
+	// changed is closed and replaced whenever an event is added, waking
+	// the watchers
+	changed chan struct{}
+}
+
+// snapshot returns the events of the job from the given index on, whether
+// it is done, and a channel closed on its next change
+func (j *scanJob) snapshot(from int) ([]jobEvent, bool, <-chan struct{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var events []jobEvent
+	if from < len(j.events) {
+		events = append(events, j.events[from:]...)
+	}
+	return events, j.done, j.changed
+}
+
+// watch calls send with each event of the job as it happens, starting
+// with those already recorded, until the job is done, send fails or ctx
+// is done
+func (j *scanJob) watch(ctx context.Context, send func(jobEvent) error) error {
+	next := 0
+	for {
+		events, done, changed := j.snapshot(next)
+		for _, event := range events {
+			if err := send(event); err != nil {
+				return err
+			}
+		}
+		next += len(events)
+		if done {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// record adds an event to the job, or marks it done when event is nil
+func (j *scanJob) record(event *jobEvent) {
+	j.mu.Lock()
+	if event != nil {
+		j.events = append(j.events, *event)
+	} else {
+		j.done = true
+	}
+	close(j.changed)
+	j.changed = make(chan struct{})
+	j.mu.Unlock()
+}
+
+// jobStore runs the scans of the server modes and keeps their results in
+// memory
+type jobStore struct {
+	ctx         context.Context
+	concurrency int
+	score       func(*http.Response, []scanner.HeaderResult) (int, string)
+
+	mu    sync.Mutex
+	jobs  map[string]*scanJob
+	order []string
+}
+
+// newJobStore returns a store running scans with the concurrency and
+// scoring of the command line until ctx is done
+func newJobStore(ctx context.Context, concurrency int, score func(*http.Response, []scanner.HeaderResult) (int, string)) *jobStore {
+	return &jobStore{ctx: ctx, concurrency: concurrency, score: score, jobs: make(map[string]*scanJob)}
+}
+
+// start starts scanning the URLs in the background and returns the job
+func (s *jobStore) start(urls []string) *scanJob {
+	id := make([]byte, 8)
+	rand.Read(id)
+	job := &scanJob{ID: hex.EncodeToString(id), Total: len(urls), changed: make(chan struct{})}
+
+	s.mu.Lock()
+	s.jobs[job.ID] = job
+	s.order = append(s.order, job.ID)
+	s.prune()
+	s.mu.Unlock()
+
+	targets := make([]scanner.Target, len(urls))
+	for i, url := range urls {
+		// The URLs come from the clients, which must not get to see the
+		// credentials, headers and cookies of the command line
+		targets[i] = scanner.Target{URL: url, Auth: scanner.Credentials{NoDefaults: true}}
+	}
+	go func() {
+		for i, outcome := range scanner.ScanTargets(s.ctx, targets, s.concurrency, s.score) {
+			scan := <-outcome
+			event := jobEvent{URL: urls[i]}
+			switch {
+			case scan.Err != nil:
+				event.Err = scan.Err.Error()
+			case scan.Skip != "":
+				event.Err, event.Skipped = scan.Skip, true
+			default:
+				result := scanner.Waive(scan.Result)
+				event.Result = &result
+			}
+			job.record(&event)
+		}
+		job.record(nil)
+	}()
+	return job
+}
+
+// get returns the job of an ID, or nil when there is none
+func (s *jobStore) get(id string) *scanJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jobs[id]
+}
+
+// prune forgets the oldest finished jobs beyond maxJobs. s.mu must be held.
+func (s *jobStore) prune() {
+	for i := 0; len(s.jobs) > maxJobs && i < len(s.order); {
+		job := s.jobs[s.order[i]]
+		job.mu.Lock()
+		done := job.done
+		job.mu.Unlock()
+		if !done {
+			i++
+			continue
+		}
+		delete(s.jobs, job.ID)
+		s.order = append(s.order[:i], s.order[i+1:]...)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+
+	"gosecurityheaders/pkg/scanner"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	TestCases  []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnit writes the results as a JUnit XML report with one test
+// suite per URL and one test case per header
+func writeJUnit(w io.Writer, results []scanner.Result) error {
+	report := junitTestSuites{Name: "gosecurityheaders"}
+	for _, result := range results {
+		suite := junitTestSuite{
+			Name: result.URL,
+			Properties: []junitProperty{
+				{Name: "grade", Value: result.Grade},
+				{Name: "score", Value: strconv.Itoa(result.Score)},
+				{Name: "retries", Value: strconv.Itoa(result.Retries)},
+				{Name: "status_code", Value: strconv.Itoa(result.StatusCode)},
+				{Name: "protocol", Value: result.Protocol},
+				{Name: "content_encoding", Value: result.ContentEncoding},
+				{Name: "address", Value: result.Address},
+				{Name: "redirects", Value: strconv.Itoa(len(result.Redirects))},
+			},
+		}
+		if result.TLS != nil {
+			suite.Properties = append(suite.Properties,
+				junitProperty{Name: "tls_version", Value: result.TLS.Version},
+				junitProperty{Name: "cipher_suite", Value: result.TLS.CipherSuite},
+				junitProperty{Name: "certificate_days_left", Value: strconv.Itoa(result.TLS.DaysLeft)},
+				junitProperty{Name: "ocsp_stapled", Value: strconv.FormatBool(result.TLS.OCSPStapled)},
+			)
+		}
+		for _, header := range result.Headers {
+			testCase := junitTestCase{
+				Name:      header.Name,
+				ClassName: result.URL,
+				SystemOut: strings.Join(append([]string{header.Value}, header.Warnings...), "\n"),
+			}
+			switch header.Status {
+			case scanner.StatusMissing:
+				testCase.Failure = &junitFailure{
+					Message: header.Name + " header is missing",
+					Type:    "MissingHeader",
+				}
+			case scanner.StatusMisconfigured:
+				testCase.Failure = &junitFailure{
+					Message: header.Name + " header is misconfigured: " + strings.Join(header.Issues, "; "),
+					Type:    "MisconfiguredHeader",
+				}
+			case scanner.StatusDeprecated:
+				testCase.Failure = &junitFailure{
+					Message: header.Name + " header is deprecated: " + strings.Join(header.Issues, "; "),
+					Type:    "DeprecatedHeader",
+				}
+			case scanner.StatusDisclosure:
+				testCase.Failure = &junitFailure{
+					Message: header.Name + " header discloses information: " + strings.Join(header.Issues, "; "),
+					Type:    "InformationDisclosure",
+				}
+			case scanner.StatusReportOnly:
+				testCase.Failure = &junitFailure{
+					Message: header.Name + " header is not enforced: " + strings.Join(header.Issues, "; "),
+					Type:    "ReportOnlyHeader",
+				}
+			}
+			if testCase.Failure != nil {
+				testCase.Failure.Message = string(header.Severity) + ": " + testCase.Failure.Message
+				suite.Failures++
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+			suite.Tests++
+		}
+
+		// Accepted risks are skipped rather than failed
+		for _, waived := range result.Waived {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      waived.Name,
+				ClassName: result.URL,
+				Skipped:   &junitSkipped{Message: "waived " + string(waived.Status) + " finding: " + waived.Reason + waivedExpiry(waived)},
+				SystemOut: strings.Join(append(append([]string{waived.Value}, waived.Issues...), waived.Warnings...), "\n"),
+			})
+			suite.Tests++
+			suite.Skipped++
+		}
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -1,195 +1,755 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
-	"encoding/csv"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"time"
+
+	"gosecurityheaders/pkg/scanner"
 
 	"github.com/fatih/color"
 )
 
 var (
-	// Define the security headers to check
-	requiredHeaders = []string{
-		"Content-Security-Policy",
-		"Strict-Transport-Security",
-		"X-Frame-Options",
-		"X-Content-Type-Options",
-		"Referrer-Policy",
-		"Permissions-Policy",
-	}
-
 	// Colors for output
-	missingColor = color.New(color.FgRed).SprintFunc()
-	presentColor = color.New(color.FgGreen).SprintFunc()
-
-	// HTTP client
-	client *http.Client
+	missingColor       = color.New(color.FgRed).SprintFunc()
+	presentColor       = color.New(color.FgGreen).SprintFunc()
+	misconfiguredColor = color.New(color.FgYellow).SprintFunc()
 )
 
-// fetchHeaders fetches the headers for a given URL
-func fetchHeaders(url string) (http.Header, error) {
-	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
-		url = "http://" + url
+// statusColor returns the console color function for a status
+func statusColor(status scanner.Status) func(a ...interface{}) string {
+	switch status {
+	case scanner.StatusPresent:
+		return presentColor
+	case scanner.StatusMisconfigured, scanner.StatusReportOnly, scanner.StatusDeprecated, scanner.StatusDisclosure:
+		return misconfiguredColor
+	default:
+		return missingColor
 	}
+}
 
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, err
+// gradeColor returns the console color function for a grade
+func gradeColor(grade string) func(a ...interface{}) string {
+	switch grade[0] {
+	case 'A':
+		return presentColor
+	case 'B', 'C':
+		return misconfiguredColor
+	default:
+		return missingColor
 	}
-	defer resp.Body.Close()
-
-	return resp.Header, nil
 }
 
-// checkHeaders checks which headers are present or missing
-func checkHeaders(headers http.Header) map[string]bool {
-	results := make(map[string]bool)
-	for _, header := range requiredHeaders {
-		_, present := headers[header]
-		results[header] = present
+// severityColor returns the console color function for a severity
+func severityColor(severity scanner.Severity) func(a ...interface{}) string {
+	switch severity {
+	case scanner.SeverityCritical:
+		return color.New(color.FgRed, color.Bold).SprintFunc()
+	case scanner.SeverityHigh:
+		return missingColor
+	case scanner.SeverityMedium:
+		return misconfiguredColor
+	default:
+		return color.New(color.FgCyan).SprintFunc()
 	}
-	return results
 }
 
 // displayResults prints the results with color coding
-func displayResults(url string, results map[string]bool) {
-	fmt.Printf("\nResults for %s:\n", url)
-	for header, present := range results {
-		if present {
-			fmt.Printf("  %s: %s\n", header, presentColor("Present"))
-		} else {
-			fmt.Printf("  %s: %s\n", header, missingColor("Missing"))
+func displayResults(r scanner.Result) {
+	fmt.Printf("\nResults for %s: grade %s (%d/100)%s\n", r.URL, gradeColor(r.Grade)(r.Grade), r.Score, responseNote(r)+retriedNote(r))
+	if r.TLS != nil {
+		fmt.Printf("  TLS: %s\n", r.TLS)
+		if r.TLS.Certificate != nil {
+			fmt.Printf("  Certificate: %s\n", r.TLS.Certificate)
 		}
 	}
+	for _, hop := range r.Redirects {
+		fmt.Printf("  Redirect: %s\n", hop)
+	}
+	for _, result := range r.Headers {
+		status := statusColor(result.Status)(string(result.Status))
+		if result.Status == scanner.StatusDisclosure {
+			status += " (" + result.Value + ")"
+		}
+		if result.Severity != "" {
+			status += " " + severityColor(result.Severity)("["+string(result.Severity)+"]")
+		}
+		fmt.Printf("  %s: %s\n", result.Name, status)
+		for _, issue := range result.Issues {
+			fmt.Printf("    - %s\n", issue)
+		}
+		for _, warning := range result.Warnings {
+			fmt.Printf("    %s %s\n", misconfiguredColor("warning:"), warning)
+		}
+	}
+	if len(r.Waived) > 0 {
+		fmt.Println("  Waived findings:")
+	}
+	for _, waived := range r.Waived {
+		fmt.Printf("    %s: %s, %s%s\n", waived.Name, waived.Status, waived.Reason, waivedExpiry(waived))
+	}
 }
 
-// writeResultsToCSV writes the results to a CSV file
-func writeResultsToCSV(filePath string, results map[string]map[string]bool) error {
-	file, err := os.Create(filePath)
-	if err != nil {
-		return err
+// responseNote notes the status code of a result along with the protocol
+// and address family that served it
+func responseNote(r scanner.Result) string {
+	note := ""
+	if r.StatusCode != 0 {
+		note += fmt.Sprintf(", status %d", r.StatusCode)
 	}
-	defer file.Close()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	// Write header row
-	header := append([]string{"URL"}, requiredHeaders...)
-	if err := writer.Write(header); err != nil {
-		return err
+	if r.Protocol != "" {
+		note += " over " + r.Protocol
+	}
+	if r.Family != "" {
+		note += " (" + r.Family + ")"
 	}
+	if r.ContentEncoding != "" {
+		note += ", " + r.ContentEncoding + " encoded"
+	}
+	return note
+}
 
-	// Write data rows
-	for url, headers := range results {
-		row := []string{url}
-		for _, header := range requiredHeaders {
-			if headers[header] {
-				row = append(row, "Present")
-			} else {
-				row = append(row, "Missing")
-			}
-		}
-		if err := writer.Write(row); err != nil {
-			return err
-		}
+// retriedNote notes that a result was only fetched after retrying
+func retriedNote(r scanner.Result) string {
+	switch r.Retries {
+	case 0:
+		return ""
+	case 1:
+		return " after 1 retry"
+	default:
+		return fmt.Sprintf(" after %d retries", r.Retries)
 	}
+}
 
-	return nil
+// waivedExpiry describes when a waived finding is reported again
+func waivedExpiry(waived scanner.WaivedFinding) string {
+	if waived.Expires == "" {
+		return ""
+	}
+	return " (until " + waived.Expires + ")"
 }
 
-// readURLsFromFile reads a list of URLs from a file
-func readURLsFromFile(filePath string) ([]string, error) {
+// readTargetsFromFile reads a list of URLs from a file. Each line holds a
+// URL optionally followed by whitespace-separated tags, e.g.
+// "https://example.com/login sensitive". Lines starting with # are ignored.
+func readTargetsFromFile(filePath string) ([]scanner.Target, error) {
 	data, err := ioutil.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
 
 	lines := strings.Split(string(data), "\n")
-	var urls []string
+	var targets []scanner.Target
 	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed != "" {
-			urls = append(urls, trimmed)
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
 		}
+		targets = append(targets, scanner.Target{URL: fields[0], Tags: fields[1:]})
 	}
-	return urls, nil
+	return targets, nil
 }
 
 func main() {
+	// The serve and daemon subcommands take the same flags as a scan,
+	// schema writes the JSON Schema of the results and diff compares two
+	// JSON result files
+	args := os.Args[1:]
+	var command string
+	if len(args) > 0 && (args[0] == "serve" || args[0] == "daemon" || args[0] == "schema" || args[0] == "diff") {
+		command, args = args[0], args[1:]
+	}
+	serve := command == "serve"
+	runDaemon := command == "daemon"
+
 	// Parse command-line flags
-	missingOnly := flag.Bool("missing", false, "Display only missing headers with URLs")
+	missingOnly := flag.Bool("missing", false, "Display only headers with findings along with their URLs")
 	skipSSL := flag.Bool("skip-ssl", false, "Skip SSL verification")
-	outputFile := flag.String("output", "", "Export results to a CSV file")
+	clientCert := flag.String("cert", "", "PEM client certificate for servers requiring mutual TLS")
+	clientKey := flag.String("key", "", "PEM private key of --cert (default read from the --cert file)")
+	flag.BoolVar(&scanner.CertDetails, "cert-details", false, "Report the subject, issuer, SANs and chain validity of TLS certificates")
+	flag.IntVar(&scanner.CertWarnDays, "cert-warn-days", 30, "Warn when the TLS certificate expires within this many days")
+	caCert := flag.String("cacert", "", "PEM file of CA certificates to trust along with the system roots, e.g. an internal CA")
+	outputFile := flag.String("output", "", "Export results to a file")
 	inputFile := flag.String("input", "", "File containing a list of URLs")
-	flag.Parse()
+	flag.BoolVar(&csvValues, "csv-values", false, "Include header values as extra CSV columns")
+	flag.BoolVar(&csvAppend, "append", false, "Append timestamped rows to the CSV output file instead of overwriting it")
+	flag.Int64Var(&scanner.HSTSMinAge, "hsts-min-age", scanner.HSTSMinAge, "Minimum Strict-Transport-Security max-age in seconds before warning")
+	flag.StringVar(&scanner.StatusHandling, "non-2xx", "report", "Handling of responses whose status is not 2xx: "+strings.Join(scanner.StatusHandlings, ", "))
+	flag.BoolVar(&scanner.RespectRobots, "respect-robots", false, "Skip URLs that robots.txt disallows for gosecurityheaders or *")
+	flag.BoolVar(&scanner.TLSProbes, "tls-probes", false, "Probe HTTPS servers with separate handshakes for TLS 1.0, TLS 1.1 and RSA key exchange")
+	flag.BoolVar(&scanner.HTTP3Checks, "http3", false, "Also fetch HTTPS URLs over HTTP/3 (QUIC) and report headers that differ per protocol")
+	flag.StringVar(&scanner.RequestMethod, "method", http.MethodGet, "Request method, e.g. HEAD to skip downloading bodies (falls back to GET when HEAD is rejected) or POST (default GET, or POST with --data)")
+	flag.StringVar(&scanner.AcceptEncoding, "accept-encoding", "", "Accept-Encoding header to send, e.g. identity or br, gzip (default gzip)")
+	data := flag.String("data", "", "Request body to send, or @file to read it from a file")
+	flag.StringVar(&scanner.RequestContentType, "content-type", "application/x-www-form-urlencoded", "Content-Type of the --data body")
+	flag.StringVar(&scanner.UserAgent, "user-agent", scanner.DefaultUserAgent, "User-Agent header to send")
+	flag.Var(&scanner.RequestHeaders, "header", "Request header to send as \"Name: value\", may be repeated")
+	flag.StringVar(&scanner.DefaultAuth.Basic, "auth-basic", "", "Credentials for HTTP basic authentication, as user:password")
+	flag.StringVar(&scanner.DefaultAuth.Bearer, "auth-bearer", "", "Token for HTTP bearer authentication")
+	flag.Var(&scanner.RequestCookies, "cookie", "Cookies to send as \"name=value\", may be repeated")
+	cookieFile := flag.String("cookie-file", "", "Netscape format cookie file, as written by curl -c, whose cookies are sent to their domains")
+	flag.StringVar(&scanner.CORSOrigin, "origin", "", "Origin header to send, so CORS checks can detect reflected origins")
+	flag.BoolVar(&scanner.ReportingChecks, "reporting", false, "Check the Reporting-Endpoints, Report-To and NEL headers and the CSP reporting directives")
+	flag.BoolVar(&scanner.SRIChecks, "sri", false, "Report third-party scripts and stylesheets loaded without Subresource Integrity")
+	flag.BoolVar(&scanner.OCSPChecks, "ocsp", false, "Report whether HTTPS servers staple OCSP responses to the TLS handshake")
+	flag.BoolVar(&scanner.CTChecks, "ct", false, "Report HTTPS certificates without Certificate Transparency SCTs")
+	ctLogList := flag.String("ct-logs", "", "Verify --ct SCT signatures against a CT log list in the v3 JSON format, e.g. log_list.json")
+	flag.BoolVar(&scanner.CAAChecks, "caa", false, "Report domains without DNS CAA records restricting which CAs may issue their certificates")
+	flag.BoolVar(&scanner.NonceChecks, "nonces", false, "Fetch pages twice to verify that their CSP nonces change between requests")
+	sensitive := flag.String("sensitive", "", "Comma-separated URL path fragments (e.g. /login,/account,/api) whose responses must not be cached")
+	logout := flag.String("logout", "", "Comma-separated URL path fragments (e.g. /logout,/signout) whose responses must send Clear-Site-Data")
+	flag.BoolVar(&scanner.PreloadChecks, "preload", false, "Verify that domains claiming HSTS preload are on the preload list, using hstspreload.org")
+	preloadList := flag.String("preload-list", "", "Check --preload against a local snapshot of the Chromium transport_security_state_static.json")
+	headerList := flag.String("headers", "", "Comma-separated headers to require instead of the default set, e.g. CSP,HSTS,X-Custom")
+	crossDomain := flag.Bool("cross-domain-policies", false, "Also require X-Permitted-Cross-Domain-Policies, which should be none")
+	disable := flag.String("disable", "", "Comma-separated checks of headers or plugins not to run, e.g. COEP,CORP")
+	profile := flag.String("profile", "default", "Check profile adjusting the required headers and recommendations: "+strings.Join(scanner.ProfileNames(), ", "))
+	policyFile := flag.String("policy", "", "YAML or JSON policy file declaring required, expected and forbidden headers")
+	opaPolicy := flag.String("opa-policy", "", "Rego policy whose deny and warn rules judge each result (see README)")
+	var plugins scanner.RepeatedFlag
+	flag.Var(&plugins, "plugin", "Program run as a custom check of each response, may be repeated (see README)")
+	ignoreFile := flag.String("ignore", "", "YAML or JSON file of accepted risks, reported as waived findings")
+	baselineFile := flag.String("baseline", "", "JSON output of an accepted scan whose findings are not reported again")
+	updateBaseline := flag.Bool("update-baseline", false, "Record the results of this scan as the new --baseline")
+	failOn := flag.String("fail-on", "", "Exit with status 2 when a finding is at least this severe: critical, high, medium, info or any")
+	severityFile := flag.String("severities", "", "YAML or JSON file mapping header names to severities (critical, high, medium, info)")
+	grading := flag.String("grading", "", "YAML or JSON file overriding the grading rubric")
+	scoring := flag.String("score", "rubric", "Scoring algorithm: "+strings.Join(scanner.ScorerNames(), ", "))
+	templateFile := flag.String("template", "", "Render results with a Go text/template file")
+	format := flag.String("format", "", "Export format (default inferred from --output, else csv): "+strings.Join(formatNames(), ", "))
+	concurrency := flag.Int("concurrency", 1, "Number of URLs to scan in parallel")
+	flag.IntVar(&scanner.HostConcurrency, "host-concurrency", 0, "Maximum number of URLs of the same host scanned in parallel, and connections to it (default unlimited)")
+	timeout := flag.Duration("timeout", 30*time.Second, "Overall timeout for each request, including redirects and reading the body")
+	ipv4 := flag.Bool("4", false, "Connect over IPv4 only")
+	ipv6 := flag.Bool("6", false, "Connect over IPv6 only")
+	flag.Var(&scanner.ResolveOverrides, "resolve", "Connect to host:port at the given address instead of resolving it, as host:port:address, may be repeated")
+	dnsServer := flag.String("dns", "", "DNS server to resolve hosts with instead of the system resolver, e.g. 1.1.1.1")
+	dohURL := flag.String("doh", "", "DNS-over-HTTPS server URL to resolve hosts with, e.g. https://cloudflare-dns.com/dns-query")
+	connectTimeout := flag.Duration("connect-timeout", 10*time.Second, "Timeout for establishing each connection")
+	tlsTimeout := flag.Duration("tls-timeout", 10*time.Second, "Timeout for each TLS handshake")
+	maxIdlePerHost := flag.Int("max-idle-conns-per-host", http.DefaultMaxIdleConnsPerHost, "Maximum idle connections kept open to each host for reuse")
+	idleTimeout := flag.Duration("idle-timeout", 90*time.Second, "How long idle connections are kept open for reuse")
+	noKeepAlive := flag.Bool("no-keepalive", false, "Open a new connection for every request instead of reusing connections")
+	headerTimeout := flag.Duration("header-timeout", 15*time.Second, "Timeout for receiving the response headers after sending a request")
+	flag.IntVar(&scanner.Retries, "retries", 2, "Number of times a failed request is retried")
+	flag.DurationVar(&scanner.RetryBackoff, "backoff", 500*time.Millisecond, "Delay before the first retry, doubled for each further one")
+	rate := flag.Float64("rate", 0, "Maximum requests per second across all hosts (default unlimited)")
+	hostRate := flag.Float64("host-rate", 0, "Maximum requests per second to each host (default unlimited)")
+	proxy := flag.String("proxy", "", "Proxy URL, e.g. http://proxy:8080 or socks5://proxy:1080 (default HTTP_PROXY/HTTPS_PROXY)")
+	socks5 := flag.String("socks5", "", "SOCKS5 proxy host:port")
+	jitter := flag.Duration("jitter", 0, "Random delay of up to this duration added to each request, e.g. 250ms")
+	grpcAddress := flag.String("grpc", "", "Serve the gRPC scanning service of pkg/scannerpb/scanner.proto on this address, e.g. 127.0.0.1:50051, instead of scanning URLs. It fetches any URL its clients send, so only listen beyond loopback with --api-token")
+	listen := flag.String("listen", "127.0.0.1:8080", "Address the REST API of the serve and daemon subcommands listens on. serve fetches any URL its clients send, so only listen beyond loopback, e.g. on :8080, with --api-token")
+	apiToken := flag.String("api-token", "", "Bearer token that clients of the serve REST API and the --grpc service must send (default GSH_API_TOKEN, else no authentication)")
+	interval := flag.Duration("interval", time.Hour, "How often the daemon subcommand and --watch rescan the targets")
+	watch := flag.Bool("watch", false, "Rescan the targets every --interval, printing only the headers that appear, disappear or change value")
+	configFile := flag.String("config", "", "YAML or JSON file of flag values and targets (default ~/"+defaultConfigFile+" if it exists)")
+	flag.CommandLine.Parse(args)
+	if command == "schema" {
+		if err := exportSchema(*outputFile); err != nil {
+			log.Fatalf("Error writing the schema: %v\n", err)
+		}
+		return
+	}
+	if command == "diff" {
+		if flag.NArg() != 2 {
+			fmt.Println("Usage: go run . diff [--format=text|json] [--output=<file>] [--fail-on=<severity>] <old.json> <new.json>")
+			os.Exit(1)
+		}
+		var reports [2]report
+		for i, path := range flag.Args() {
+			var err error
+			if reports[i], err = loadReport(path); err != nil {
+				log.Fatalf("Error reading %s: %v\n", path, err)
+			}
+		}
+		d := diffReports(reports[0], reports[1])
+		if err := exportDiff(*outputFile, *format, d); err != nil {
+			log.Fatalf("Error writing the diff: %v\n", err)
+		}
+		if *failOn != "" {
+			threshold, err := scanner.ParseFailOn(*failOn)
+			if err != nil {
+				log.Fatalf("Unsupported --fail-on threshold: %v\n", err)
+			}
+			if d.meetsSeverity(threshold) {
+				fmt.Fprintf(os.Stderr, "Findings of %s severity or higher were added or changed\n", strings.ToLower(string(threshold)))
+				os.Exit(2)
+			}
+		}
+		return
+	}
+
+	// Environment variables apply unless the flag is set on the command
+	// line, and the config file unless either sets it
+	if err := loadEnv(); err != nil {
+		log.Fatalf("Error reading environment: %v\n", err)
+	}
+	var targets []scanner.Target
+	if path := configPath(*configFile); path != "" {
+		configTargets, err := loadConfig(path)
+		if err != nil {
+			log.Fatalf("Error loading config %s: %v\n", path, err)
+		}
+		targets = append(targets, configTargets...)
+	}
 
 	// Get URLs from command-line arguments
-	urls := flag.Args()
+	for _, url := range flag.Args() {
+		targets = append(targets, scanner.Target{URL: url})
+	}
 
 	// Read URLs from input file if specified
 	if *inputFile != "" {
-		fileURLs, err := readURLsFromFile(*inputFile)
+		fileTargets, err := readTargetsFromFile(*inputFile)
 		if err != nil {
 			log.Fatalf("Error reading URLs from file: %v\n", err)
 		}
-		urls = append(urls, fileURLs...)
+		targets = append(targets, fileTargets...)
+	}
+	if !scanner.IsStatusHandling(scanner.StatusHandling) {
+		log.Fatalf("Unsupported --non-2xx handling: %s\n", scanner.StatusHandling)
+	}
+	if *data != "" {
+		body, err := scanner.LoadRequestData(*data)
+		if err != nil {
+			log.Fatalf("Error reading --data: %v\n", err)
+		}
+		scanner.RequestBody = body
+		// Like curl, a body without a method is POSTed
+		methodSet := false
+		flag.Visit(func(f *flag.Flag) {
+			methodSet = methodSet || f.Name == "method"
+		})
+		if !methodSet {
+			scanner.RequestMethod = http.MethodPost
+		}
+	}
+	scanner.RequestMethod = strings.ToUpper(scanner.RequestMethod)
+	if !scanner.ValidMethod(scanner.RequestMethod) {
+		log.Fatalf("Invalid method: %s\n", scanner.RequestMethod)
+	}
+	if scanner.RequestMethod == http.MethodHead && len(scanner.RequestBody) > 0 {
+		log.Fatalf("--data cannot be sent with --method=HEAD\n")
+	}
+	if scanner.RequestMethod == http.MethodHead && scanner.SRIChecks {
+		log.Fatalf("--sri requires --method=GET, since HEAD responses have no body\n")
+	}
+	if err := scanner.DefaultAuth.Validate(); err != nil {
+		log.Fatalf("Invalid authentication: %v\n", err)
+	}
+	if *cookieFile != "" {
+		if err := scanner.LoadCookieFile(*cookieFile); err != nil {
+			log.Fatalf("Error loading cookies from %s: %v\n", *cookieFile, err)
+		}
+	}
+	scanner.SensitivePaths = scanner.SplitList(*sensitive)
+	scanner.LogoutPaths = scanner.SplitList(*logout)
+	if *headerList != "" {
+		scanner.SelectHeaders(scanner.ParseHeaderList(*headerList))
+	}
+	if !scanner.ApplyProfile(*profile) {
+		log.Fatalf("Unsupported profile: %s\n", *profile)
+	}
+	if *crossDomain {
+		scanner.RequireHeader("X-Permitted-Cross-Domain-Policies")
+	}
+	if *policyFile != "" {
+		if err := scanner.LoadPolicy(*policyFile); err != nil {
+			log.Fatalf("Error loading policy: %v\n", err)
+		}
+	}
+	if *opaPolicy != "" {
+		if err := scanner.LoadOPAPolicy(*opaPolicy); err != nil {
+			log.Fatalf("Error loading OPA policy: %v\n", err)
+		}
+	}
+	for _, path := range plugins {
+		check, err := scanner.NewCommandCheck(path)
+		if err != nil {
+			log.Fatalf("Error loading plugin %s: %v\n", path, err)
+		}
+		for _, registered := range scanner.Registered() {
+			if strings.EqualFold(registered.ID(), check.ID()) {
+				log.Fatalf("Plugin %s: check %s is already registered\n", path, check.ID())
+			}
+		}
+		scanner.Register(check)
+	}
+	for _, id := range scanner.ParseHeaderList(*disable) {
+		if !scanner.Disable(id) {
+			log.Fatalf("Unknown check: %s\n", id)
+		}
+	}
+	score, ok := scanner.Scorers[*scoring]
+	if !ok {
+		log.Fatalf("Unsupported scoring algorithm: %s\n", *scoring)
+	}
+	if *severityFile != "" {
+		if err := scanner.LoadSeverities(*severityFile); err != nil {
+			log.Fatalf("Error loading severities: %v\n", err)
+		}
+	}
+	if *preloadList != "" {
+		if err := scanner.LoadPreloadSnapshot(*preloadList); err != nil {
+			log.Fatalf("Error loading preload list: %v\n", err)
+		}
+		scanner.PreloadChecks = true
+	}
+	if *ctLogList != "" {
+		if err := scanner.LoadCTLogs(*ctLogList); err != nil {
+			log.Fatalf("Error loading CT log list: %v\n", err)
+		}
+		scanner.CTChecks = true
+	}
+	if *ignoreFile != "" {
+		if err := scanner.LoadIgnoreFile(*ignoreFile); err != nil {
+			log.Fatalf("Error loading ignore file: %v\n", err)
+		}
+	}
+	var threshold scanner.Severity
+	if *failOn != "" {
+		var err error
+		if threshold, err = scanner.ParseFailOn(*failOn); err != nil {
+			log.Fatalf("Unsupported --fail-on threshold: %v\n", err)
+		}
+	}
+	var known baseline
+	if *baselineFile != "" {
+		var err error
+		if known, err = loadBaseline(*baselineFile); err != nil {
+			log.Fatalf("Error loading baseline: %v\n", err)
+		}
+	} else if *updateBaseline {
+		log.Fatalf("--update-baseline requires --baseline\n")
+	}
+	if *grading != "" {
+		if err := scanner.LoadRubric(*grading); err != nil {
+			log.Fatalf("Error loading grading rubric: %v\n", err)
+		}
 	}
 
-	if len(urls) == 0 {
-		fmt.Println("Usage: go run main.go [--missing] [--skip-ssl] [--input=<file>] [--output=<file.csv>] <URL1> <URL2> ...")
+	if *concurrency < 1 {
+		log.Fatalf("--concurrency must be at least 1\n")
+	}
+	if *maxIdlePerHost < 0 || *idleTimeout < 0 {
+		log.Fatalf("--max-idle-conns-per-host and --idle-timeout cannot be negative\n")
+	}
+	if scanner.HostConcurrency < 0 {
+		log.Fatalf("--host-concurrency cannot be negative\n")
+	}
+	if scanner.Retries < 0 || scanner.RetryBackoff < 0 {
+		log.Fatalf("--retries and --backoff cannot be negative\n")
+	}
+	if *rate < 0 || *hostRate < 0 || *jitter < 0 {
+		log.Fatalf("--rate, --host-rate and --jitter cannot be negative\n")
+	}
+	if (serve || runDaemon) && *grpcAddress != "" {
+		log.Fatalf("--grpc cannot be used with serve or daemon\n")
+	}
+	if *watch && (serve || runDaemon || *grpcAddress != "") {
+		log.Fatalf("--watch cannot be used with the servers or daemon\n")
+	}
+	if (runDaemon || *watch) && *interval <= 0 {
+		log.Fatalf("--interval must be positive\n")
+	}
+	if (runDaemon || *watch) && *updateBaseline {
+		log.Fatalf("--update-baseline cannot be used with daemon or --watch\n")
+	}
+	if *watch && (*outputFile != "" || *format != "" || *templateFile != "") {
+		log.Fatalf("--watch prints its changes to the console, so it cannot be combined with --output, --format or --template\n")
+	}
+	if (serve || *grpcAddress != "") && len(targets) > 0 {
+		log.Fatalf("The servers scan the URLs their clients request, not those given to them\n")
+	}
+	if len(targets) == 0 && *grpcAddress == "" && !serve {
+		fmt.Println("Usage: go run . [--missing] [--skip-ssl] [--input=<file>] [--output=<file>] [--format=<format>|--template=<file>] <URL1> <URL2> ...")
+		fmt.Println("       go run . serve [--listen=<address>] [flags]")
+		fmt.Println("       go run . daemon [--interval=<duration>] [--listen=<address>] [flags] <URL1> <URL2> ...")
+		fmt.Println("       go run . schema [--output=<file>]")
+		fmt.Println("       go run . diff [--format=text|json] [--output=<file>] <old.json> <new.json>")
 		os.Exit(1)
 	}
 
+	// Without an output file, an explicit format is written to stdout
+	// instead of the console display
+	toStdout := *outputFile == "" && (*format != "" || *templateFile != "")
+	if *templateFile != "" {
+		if *format != "" {
+			log.Fatalf("--template cannot be combined with --format\n")
+		}
+		write, err := templateWriter(*templateFile)
+		if err != nil {
+			log.Fatalf("Error parsing template: %v\n", err)
+		}
+		writers["template"] = write
+		*format = "template"
+	}
+	if *format == "" {
+		*format = formatForFile(*outputFile)
+	}
+	if !isFormat(*format) {
+		log.Fatalf("Unsupported output format: %s\n", *format)
+	}
+	if _, ok := writers[*format]; toStdout && !ok {
+		log.Fatalf("The %s format requires --output\n", *format)
+	}
+	if csvAppend && (*format != "csv" || *outputFile == "") {
+		log.Fatalf("--append requires CSV output to a file\n")
+	}
+
 	// Configure HTTP client
+	proxyFor, err := scanner.ProxyFunc(*proxy, *socks5)
+	if err != nil {
+		log.Fatalf("Invalid proxy: %v\n", err)
+	}
+	if scanner.HTTP3Checks && (*proxy != "" || *socks5 != "") {
+		log.Fatalf("--http3 cannot be sent through --proxy or --socks5\n")
+	}
+	if scanner.TLSProbes && (*proxy != "" || *socks5 != "") {
+		log.Fatalf("--tls-probes cannot be sent through --proxy or --socks5\n")
+	}
+	switch {
+	case *ipv4 && *ipv6:
+		log.Fatalf("-4 and -6 cannot be combined\n")
+	case *ipv4:
+		scanner.IPNetwork = "tcp4"
+	case *ipv6:
+		scanner.IPNetwork = "tcp6"
+	}
+	// Legacy protocols and weak suites are offered so that servers still
+	// accepting them are reported rather than unreachable
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: *skipSSL,
+		MinVersion:         tls.VersionTLS10,
+		CipherSuites:       scanner.ScanCipherSuites(),
+	}
+	if *caCert != "" {
+		roots, err := scanner.LoadCACert(*caCert)
+		if err != nil {
+			log.Fatalf("Error loading CA certificates from %s: %v\n", *caCert, err)
+		}
+		tlsConfig.RootCAs, scanner.CertRoots = roots, roots
+	}
+	if *clientKey != "" && *clientCert == "" {
+		log.Fatalf("--key requires --cert\n")
+	}
+	if *clientCert != "" {
+		cert, err := scanner.LoadClientCert(*clientCert, *clientKey)
+		if err != nil {
+			log.Fatalf("Error loading client certificate: %v\n", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	dialer := &net.Dialer{Timeout: *connectTimeout}
+	switch {
+	case *dnsServer != "" && *dohURL != "":
+		log.Fatalf("--dns and --doh cannot be combined\n")
+	case *dnsServer != "":
+		scanner.LookupHost = scanner.DNSResolver(*dnsServer, dialer).LookupHost
+		scanner.DNSExchange = scanner.UDPExchanger(*dnsServer, dialer)
+	case *dohURL != "":
+		// The resolver gets its own configuration, trusting --cacert but
+		// without the legacy protocols and weak suites of the scans
+		dohConfig := &tls.Config{RootCAs: tlsConfig.RootCAs}
+		scanner.DNSExchange = scanner.DoHExchanger(*dohURL, dohConfig, *timeout)
+		scanner.LookupHost = scanner.DoHResolver(scanner.DNSExchange, *dohURL)
+	case scanner.CAAChecks:
+		server, err := scanner.SystemNameserver()
+		if err != nil {
+			log.Fatalf("Error finding a DNS server for --caa, pass --dns or --doh: %v\n", err)
+		}
+		scanner.DNSExchange = scanner.UDPExchanger(server, dialer)
+	}
 	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: *skipSSL},
+		Proxy:                 proxyFor,
+		ForceAttemptHTTP2:     true,
+		MaxConnsPerHost:       scanner.HostConcurrency,
+		MaxIdleConnsPerHost:   *maxIdlePerHost,
+		IdleConnTimeout:       *idleTimeout,
+		DisableKeepAlives:     *noKeepAlive,
+		DialContext:           scanner.DialContext(dialer),
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   *tlsTimeout,
+		ResponseHeaderTimeout: *headerTimeout,
+	}
+	scanner.Client = &http.Client{Transport: tr, Timeout: *timeout}
+	var limiter *scanner.RateLimiter
+	if *rate > 0 || *hostRate > 0 || *jitter > 0 {
+		limiter = scanner.NewRateLimiter(*rate, *hostRate, *jitter)
+		scanner.Client.Transport = &scanner.RateLimitedTransport{Next: tr, Limiter: limiter}
+	}
+	if scanner.HTTP3Checks {
+		scanner.HTTP3Client = scanner.NewHTTP3Client(tr.TLSClientConfig, *tlsTimeout, *timeout, limiter)
+	}
+	if scanner.TLSProbes {
+		scanner.LegacyProber = scanner.NewTLSProber(tr.TLSClientConfig, tr.DialContext, *tlsTimeout, limiter)
+	}
+
+	// An interrupt abandons the URLs not scanned yet, so the results of the
+	// others are still reported and exported, and stops the server modes
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	go func() {
+		// A second interrupt exits right away
+		<-ctx.Done()
+		stop()
+	}()
+	if *grpcAddress != "" {
+		log.Printf("Serving gRPC on %s\n", *grpcAddress)
+		if err := serveGRPC(ctx, *grpcAddress, *apiToken, newJobStore(ctx, *concurrency, score)); err != nil {
+			log.Fatalf("Error serving gRPC: %v\n", err)
+		}
+		return
+	}
+	if serve {
+		log.Printf("Serving the REST API on %s\n", *listen)
+		if err := serveREST(ctx, *listen, *apiToken, newJobStore(ctx, *concurrency, score)); err != nil {
+			log.Fatalf("Error serving the REST API: %v\n", err)
+		}
+		return
+	}
+	if runDaemon || *watch {
+		d := &daemon{
+			targets:     targets,
+			interval:    *interval,
+			concurrency: *concurrency,
+			score:       score,
+			filter: func(result scanner.Result) scanner.Result {
+				return scanner.Waive(known.filter(result))
+			},
+		}
+		if *watch {
+			d.export = newHeaderWatch().report
+			d.schedule(ctx)
+			return
+		}
+		if toStdout {
+			d.export = func(results []scanner.Result) error { return writers[*format](os.Stdout, results) }
+		} else if *outputFile != "" {
+			d.export = func(results []scanner.Result) error { return writeResults(*outputFile, *format, results) }
+		}
+		log.Printf("Scanning %d URLs every %s, serving their status on %s\n", len(targets), *interval, *listen)
+		if err := d.run(ctx, *listen); err != nil {
+			log.Fatalf("Error serving the daemon status: %v\n", err)
+		}
+		return
 	}
-	client = &http.Client{Transport: tr}
 
-	// Collect results for CSV export
-	resultsForCSV := make(map[string]map[string]bool)
+	// Streaming formats are written as each URL finishes, everything else
+	// is collected for export at the end
+	var stream io.Writer
+	if streamFormats[*format] {
+		stream = os.Stdout
+		if !toStdout {
+			file, err := os.Create(*outputFile)
+			if err != nil {
+				log.Fatalf("Error creating %s: %v\n", *outputFile, err)
+			}
+			defer file.Close()
+			stream = file
+		}
+	}
+	var allResults, scanned []scanner.Result
+	failed := false
 
-	// Process each URL
-	for _, url := range urls {
-		headers, err := fetchHeaders(url)
-		if err != nil {
-			log.Printf("Error fetching headers for %s: %v\n", url, err)
+	// Process each URL, reporting in input order as the workers finish
+	for i, outcome := range scanner.ScanTargets(ctx, targets, *concurrency, score) {
+		url := targets[i].URL
+		scan := <-outcome
+		if scan.Err != nil {
+			log.Printf("Error fetching headers for %s: %v\n", url, scan.Err)
+			continue
+		}
+		if scan.Skip != "" {
+			log.Printf("Skipping %s: %s\n", url, scan.Skip)
 			continue
 		}
 
-		results := checkHeaders(headers)
-		resultsForCSV[url] = results
+		result := scan.Result
+		if *updateBaseline {
+			scanned = append(scanned, result)
+		}
+		result = scanner.Waive(known.filter(result))
+		results := result.Headers
+		if threshold != "" && result.Grade != scanner.UngradedGrade && scanner.MeetsSeverity(results, threshold) {
+			failed = true
+		}
+		if stream != nil {
+			if err := writers[*format](stream, []scanner.Result{result}); err != nil {
+				log.Fatalf("Error writing %s output: %v\n", *format, err)
+			}
+		} else {
+			allResults = append(allResults, result)
+		}
 
+		if toStdout {
+			continue
+		}
 		if *missingOnly {
-			var missingHeaders []string
-			for header, present := range results {
-				if !present {
-					missingHeaders = append(missingHeaders, header)
-				}
+			if missing := scanner.MissingHeaders(results); len(missing) > 0 {
+				fmt.Printf("%s is missing: %s\n", url, strings.Join(missing, ", "))
+			}
+			if misconfigured := scanner.MisconfiguredHeaders(results); len(misconfigured) > 0 {
+				fmt.Printf("%s is misconfigured: %s\n", url, strings.Join(misconfigured, ", "))
+			}
+			if reportOnly := scanner.HeadersWithStatus(results, scanner.StatusReportOnly); len(reportOnly) > 0 {
+				fmt.Printf("%s only reports: %s\n", url, strings.Join(reportOnly, ", "))
+			}
+			if deprecated := scanner.HeadersWithStatus(results, scanner.StatusDeprecated); len(deprecated) > 0 {
+				fmt.Printf("%s sends deprecated: %s\n", url, strings.Join(deprecated, ", "))
 			}
-			if len(missingHeaders) > 0 {
-				fmt.Printf("%s is missing: %s\n", url, strings.Join(missingHeaders, ", "))
+			if disclosure := scanner.HeadersWithStatus(results, scanner.StatusDisclosure); len(disclosure) > 0 {
+				fmt.Printf("%s discloses: %s\n", url, strings.Join(disclosure, ", "))
+			}
+			if len(result.Waived) > 0 {
+				var waived []string
+				for _, finding := range result.Waived {
+					waived = append(waived, finding.Name)
+				}
+				fmt.Printf("%s waives: %s\n", url, strings.Join(waived, ", "))
 			}
 		} else {
-			displayResults(url, results)
+			displayResults(result)
+		}
+	}
+
+	if *updateBaseline {
+		if err := writeBaseline(*baselineFile, scanned); err != nil {
+			log.Fatalf("Error writing baseline: %v\n", err)
 		}
 	}
 
-	// Export to CSV if specified
-	if *outputFile != "" {
-		err := writeResultsToCSV(*outputFile, resultsForCSV)
+	// Export results if specified
+	if stream != nil {
+		if !toStdout {
+			fmt.Printf("\nResults exported to %s\n", *outputFile)
+		}
+	} else if toStdout {
+		if err := writers[*format](os.Stdout, allResults); err != nil {
+			log.Fatalf("Error writing %s output: %v\n", *format, err)
+		}
+	} else if *outputFile != "" {
+		err := writeResults(*outputFile, *format, allResults)
 		if err != nil {
-			log.Fatalf("Error writing to CSV: %v\n", err)
+			log.Fatalf("Error writing to %s: %v\n", *format, err)
 		}
 		fmt.Printf("\nResults exported to %s\n", *outputFile)
 	}
-}
 
+	if failed {
+		fmt.Fprintf(os.Stderr, "Findings of %s severity or higher were reported\n", strings.ToLower(string(threshold)))
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"gosecurityheaders/pkg/scanner"
+)
+
+// markdownStatusIcons prefixes each status in Markdown tables
+var markdownStatusIcons = map[scanner.Status]string{
+	scanner.StatusPresent:       "✅",
+	scanner.StatusMisconfigured: "⚠️",
+	scanner.StatusReportOnly:    "📝",
+	scanner.StatusDeprecated:    "🗑️",
+	scanner.StatusDisclosure:    "🔍",
+	scanner.StatusMissing:       "❌",
+}
+
+// markdownEscape escapes characters that would break a Markdown table cell
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ", "`", "'").Replace(s)
+}
+
+// writeMarkdown writes the results as Markdown with one table per URL
+func writeMarkdown(w io.Writer, results []scanner.Result) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# Security Headers Report")
+	for _, result := range results {
+		fmt.Fprintf(bw, "\n## %s\n\n", markdownEscape(result.URL))
+		fmt.Fprintf(bw, "**Grade %s** (%d/100)%s\n\n", result.Grade, result.Score, responseNote(result)+retriedNote(result))
+		if result.TLS != nil {
+			fmt.Fprintf(bw, "- TLS: %s\n", markdownEscape(result.TLS.String()))
+			if result.TLS.Certificate != nil {
+				fmt.Fprintf(bw, "- Certificate: %s\n", markdownEscape(result.TLS.Certificate.String()))
+			}
+		}
+		for _, hop := range result.Redirects {
+			fmt.Fprintf(bw, "- Redirect: %s\n", markdownEscape(hop.String()))
+		}
+		if result.TLS != nil || len(result.Redirects) > 0 {
+			fmt.Fprintln(bw)
+		}
+		fmt.Fprintln(bw, "| Header | Status | Severity | Value | Notes |")
+		fmt.Fprintln(bw, "| --- | --- | --- | --- | --- |")
+		for _, header := range result.Headers {
+			value := ""
+			if header.Present {
+				value = "`" + markdownEscape(header.Value) + "`"
+			}
+			var notes []string
+			for _, issue := range header.Issues {
+				notes = append(notes, markdownEscape(issue))
+			}
+			for _, warning := range header.Warnings {
+				notes = append(notes, "Warning: "+markdownEscape(warning))
+			}
+			fmt.Fprintf(bw, "| %s | %s %s | %s | %s | %s |\n", header.Name,
+				markdownStatusIcons[header.Status], header.Status, header.Severity, value, strings.Join(notes, "<br>"))
+		}
+		if len(result.Waived) > 0 {
+			fmt.Fprint(bw, "\n### Waived findings\n\n")
+			fmt.Fprintln(bw, "| Header | Status | Severity | Reason | Expires |")
+			fmt.Fprintln(bw, "| --- | --- | --- | --- | --- |")
+		}
+		for _, waived := range result.Waived {
+			fmt.Fprintf(bw, "| %s | %s | %s | %s | %s |\n", waived.Name, waived.Status, waived.Severity,
+				markdownEscape(waived.Reason), waived.Expires)
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"gosecurityheaders/pkg/scanner"
+)
+
+// writers maps each supported export format to its writer
+var writers = map[string]func(io.Writer, []scanner.Result) error{
+	"csv":        writeCSV,
+	"html":       writeHTML,
+	"influx":     writeInflux,
+	"json":       writeJSON,
+	"junit":      writeJUnit,
+	"markdown":   writeMarkdown,
+	"ndjson":     writeNDJSON,
+	"pdf":        writePDF,
+	"prometheus": writePrometheus,
+	"sarif":      writeSARIF,
+	"yaml":       writeYAML,
+}
+
+// streamFormats lists the export formats that are written as each URL
+// finishes instead of after the whole scan
+var streamFormats = map[string]bool{
+	"ndjson": true,
+}
+
+// fileWriters maps export formats that manage the output file themselves,
+// such as databases that are appended to rather than overwritten
+var fileWriters = map[string]func(string, []scanner.Result) error{
+	"sqlite": writeSQLite,
+}
+
+// formatExtensions maps output file extensions to their implied format
+var formatExtensions = map[string]string{
+	".db":      "sqlite",
+	".htm":     "html",
+	".html":    "html",
+	".json":    "json",
+	".jsonl":   "ndjson",
+	".ndjson":  "ndjson",
+	".pdf":     "pdf",
+	".prom":    "prometheus",
+	".md":      "markdown",
+	".sarif":   "sarif",
+	".sqlite":  "sqlite",
+	".sqlite3": "sqlite",
+	".yaml":    "yaml",
+	".yml":     "yaml",
+}
+
+// formatNames returns the supported export formats in sorted order
+func formatNames() []string {
+	var names []string
+	for name := range writers {
+		names = append(names, name)
+	}
+	for name := range fileWriters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isFormat reports whether the format is supported
+func isFormat(format string) bool {
+	_, ok := writers[format]
+	if !ok {
+		_, ok = fileWriters[format]
+	}
+	return ok
+}
+
+// formatForFile infers the export format from the output file extension,
+// defaulting to CSV
+func formatForFile(filePath string) string {
+	if format, ok := formatExtensions[strings.ToLower(filepath.Ext(filePath))]; ok {
+		return format
+	}
+	return "csv"
+}
+
+// writeResults writes the results to a file in the given format
+func writeResults(filePath, format string, results []scanner.Result) error {
+	if format == "csv" && csvAppend {
+		return appendCSV(filePath, results)
+	}
+	if writeFile, ok := fileWriters[format]; ok {
+		return writeFile(filePath, results)
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := writers[format](file, results); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+var (
+	// csvValues adds a value column after each header status in CSV output
+	csvValues bool
+
+	// csvAppend appends rows stamped with the scan time to the CSV file
+	// instead of overwriting it
+	csvAppend bool
+)
+
+// writeCSV writes the results as CSV
+func writeCSV(w io.Writer, results []scanner.Result) error {
+	return encodeCSV(w, results, true)
+}
+
+// appendCSV appends the results to a CSV file, writing the header row only
+// when the file is new or empty. An existing file must start with the same
+// header row, or the appended rows wouldn't line up with its columns.
+func appendCSV(filePath string, results []scanner.Result) error {
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > 0 {
+		existing, err := csv.NewReader(file).Read()
+		if err != nil {
+			return fmt.Errorf("reading the header row of %s: %v", filePath, err)
+		}
+		if !slices.Equal(existing, csvHeader()) {
+			return fmt.Errorf("%s has other columns than this scan writes, e.g. from another --csv-values or --headers setting, so its rows can't be appended", filePath)
+		}
+	}
+	if err := encodeCSV(file, results, info.Size() == 0); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// csvHeader returns the header row of the CSV output
+func csvHeader() []string {
+	header := []string{"URL", "Grade", "Score", "Highest Severity"}
+	if csvAppend {
+		header = append([]string{"Scanned At"}, header...)
+	}
+	for _, name := range scanner.RequiredHeaders() {
+		header = append(header, name)
+		if csvValues {
+			header = append(header, name+" Value")
+		}
+	}
+	return append(header, "Additional Findings", "Waived Findings", "Redirects", "Status Code", "Content Encoding", "Certificate Expires", "TLS Version", "Cipher Suite", "OCSP Stapled")
+}
+
+// encodeCSV writes the CSV rows, optionally preceded by the header row
+func encodeCSV(w io.Writer, results []scanner.Result, withHeader bool) error {
+	writer := csv.NewWriter(w)
+	scannedAt := time.Now().UTC().Format(time.RFC3339)
+	required := scanner.RequiredHeaders()
+
+	// Write header row
+	if withHeader {
+		if err := writer.Write(csvHeader()); err != nil {
+			return err
+		}
+	}
+
+	// Write data rows
+	for _, result := range results {
+		row := []string{result.URL, result.Grade, strconv.Itoa(result.Score), string(scanner.HighestSeverity(result.Headers))}
+		if csvAppend {
+			row = append([]string{scannedAt}, row...)
+		}
+		for _, name := range required {
+			header, _ := scanner.FindHeader(result.Headers, name)
+			row = append(row, string(header.Status))
+			if csvValues {
+				row = append(row, header.Value)
+			}
+		}
+
+		// Headers outside the required set, such as deprecated ones, vary
+		// per URL so they share a single column
+		var additional []string
+		for _, header := range result.Headers {
+			if !scanner.IsRequiredHeader(header.Name) {
+				additional = append(additional, header.Name+": "+string(header.Status))
+			}
+		}
+		var waived []string
+		for _, finding := range result.Waived {
+			waived = append(waived, finding.Name+": "+string(finding.Status)+" ("+finding.Reason+")")
+		}
+		var redirects []string
+		for _, hop := range result.Redirects {
+			redirects = append(redirects, hop.String())
+		}
+		var certificateExpires, tlsVersion, cipherSuite, ocspStapled string
+		if result.TLS != nil {
+			certificateExpires = result.TLS.Expires.Format("2006-01-02")
+			tlsVersion, cipherSuite = result.TLS.Version, result.TLS.CipherSuite
+			ocspStapled = strconv.FormatBool(result.TLS.OCSPStapled)
+		}
+		row = append(row, strings.Join(additional, "; "), strings.Join(waived, "; "), strings.Join(redirects, "; "), strconv.Itoa(result.StatusCode), result.ContentEncoding, certificateExpires, tlsVersion, cipherSuite, ocspStapled)
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// report is the top-level structure shared by the JSON and YAML output
+type report struct {
+	SchemaVersion string           `json:"schema_version" yaml:"schema_version"`
+	Results       []scanner.Result `json:"results" yaml:"results"`
+}
+
+// newReport builds the top-level report for the results
+func newReport(results []scanner.Result) report {
+	if results == nil {
+		results = []scanner.Result{}
+	}
+	return report{SchemaVersion: scanner.SchemaVersion, Results: results}
+}
+
+// writeJSON writes the results as indented JSON
+func writeJSON(w io.Writer, results []scanner.Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(newReport(results))
+}
+
+// ndjsonResult is a line of the NDJSON output, a result along with the
+// version of its schema
+type ndjsonResult struct {
+	SchemaVersion string `json:"schema_version"`
+	scanner.Result
+}
+
+// writeNDJSON writes each result as a single line of JSON
+func writeNDJSON(w io.Writer, results []scanner.Result) error {
+	encoder := json.NewEncoder(w)
+	for _, result := range results {
+		if err := encoder.Encode(ndjsonResult{scanner.SchemaVersion, result}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeYAML writes the results as YAML mirroring the JSON structure
+func writeYAML(w io.Writer, results []scanner.Result) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(newReport(results)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gosecurityheaders/pkg/scanner"
+)
+
+// Page geometry in PDF points (A4)
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 50.0
+)
+
+// pdfColor is an RGB fill color with components in the range 0-1
+type pdfColor [3]float64
+
+var (
+	pdfBlack = pdfColor{0, 0, 0}
+	pdfGray  = pdfColor{0.4, 0.4, 0.4}
+	pdfGreen = pdfColor{0.1, 0.5, 0.21}
+	pdfRed   = pdfColor{0.81, 0.13, 0.18}
+	pdfAmber = pdfColor{0.6, 0.4, 0}
+)
+
+// pdfStatusColors maps each status to its color in the report
+var pdfStatusColors = map[scanner.Status]pdfColor{
+	scanner.StatusPresent:       pdfGreen,
+	scanner.StatusMisconfigured: pdfAmber,
+	scanner.StatusReportOnly:    pdfAmber,
+	scanner.StatusDeprecated:    pdfAmber,
+	scanner.StatusDisclosure:    pdfAmber,
+	scanner.StatusMissing:       pdfRed,
+}
+
+// pdfDocument is a minimal multi-page PDF writer using the standard
+// Helvetica fonts, which keeps reports free of external dependencies
+type pdfDocument struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64
+}
+
+// newPDFDocument creates a document with an empty first page
+func newPDFDocument() *pdfDocument {
+	doc := &pdfDocument{}
+	doc.addPage()
+	return doc
+}
+
+// addPage starts a new page and moves the cursor to its top margin
+func (d *pdfDocument) addPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+	d.y = pdfPageHeight - pdfMargin
+}
+
+// ensureSpace starts a new page if less than height points remain
+func (d *pdfDocument) ensureSpace(height float64) {
+	if d.y-height < pdfMargin+20 {
+		d.addPage()
+	}
+}
+
+// textAt draws a single line of text at an absolute position
+func (d *pdfDocument) textAt(page *bytes.Buffer, x, y float64, bold bool, size float64, color pdfColor, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(page, "BT %.2f %.2f %.2f rg /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n",
+		color[0], color[1], color[2], font, size, x, y, pdfEscape(text))
+}
+
+// text draws text at x on the current line without advancing the cursor
+func (d *pdfDocument) text(x float64, bold bool, size float64, color pdfColor, text string) {
+	d.textAt(d.page, x, d.y, bold, size, color, text)
+}
+
+// line draws text at x and advances the cursor to the next line, wrapping
+// long text onto further lines
+func (d *pdfDocument) line(x float64, bold bool, size float64, color pdfColor, text string) {
+	// Helvetica averages roughly half an em per character
+	maxChars := int((pdfPageWidth - pdfMargin - x) / (size * 0.5))
+	for _, part := range pdfWrap(text, maxChars) {
+		d.ensureSpace(size * 1.4)
+		d.text(x, bold, size, color, part)
+		d.y -= size * 1.4
+	}
+}
+
+// space advances the cursor by the given number of points
+func (d *pdfDocument) space(points float64) {
+	d.y -= points
+}
+
+// writeTo serializes the document, adding a footer to every page
+func (d *pdfDocument) writeTo(w io.Writer) error {
+	for i, page := range d.pages {
+		d.textAt(page, pdfMargin, pdfMargin-20, false, 8, pdfGray, "gosecurityheaders")
+		d.textAt(page, pdfPageWidth-pdfMargin-50, pdfMargin-20, false, 8, pdfGray,
+			fmt.Sprintf("Page %d of %d", i+1, len(d.pages)))
+	}
+
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	// Objects 1-4 are the catalog, page tree and fonts; each page then
+	// takes two objects for the page and its content stream
+	var kids []string
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 5+i*2))
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+i*2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// pdfGradeColor returns the report color for a grade
+func pdfGradeColor(grade string) pdfColor {
+	switch {
+	case strings.HasPrefix(grade, "A"):
+		return pdfGreen
+	case strings.HasPrefix(grade, "B"), strings.HasPrefix(grade, "C"):
+		return pdfAmber
+	default:
+		return pdfRed
+	}
+}
+
+// pdfEscape escapes a string for use in a PDF literal string, replacing
+// characters outside of Latin-1 since the standard fonts cannot show them
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r < 128:
+			b.WriteRune(r)
+		case r < 256:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// pdfWrap splits text into lines of at most maxChars characters, breaking
+// at spaces where possible
+func pdfWrap(text string, maxChars int) []string {
+	if maxChars < 1 {
+		maxChars = 1
+	}
+	var lines []string
+	runes := []rune(text)
+	for len(runes) > maxChars {
+		cut := maxChars
+		for i := maxChars; i > 0; i-- {
+			if runes[i] == ' ' {
+				cut = i
+				break
+			}
+		}
+		lines = append(lines, string(runes[:cut]))
+		runes = []rune(strings.TrimLeft(string(runes[cut:]), " "))
+	}
+	return append(lines, string(runes))
+}
+
+// writePDF writes the results as a paginated PDF report with a summary
+// followed by the details for each target
+func writePDF(w io.Writer, results []scanner.Result) error {
+	doc := newPDFDocument()
+
+	doc.line(pdfMargin, true, 20, pdfBlack, "Security Headers Report")
+	doc.line(pdfMargin, false, 10, pdfGray, "Generated "+time.Now().Format(time.RFC1123))
+	doc.space(10)
+
+	// Summary
+	counts := make(map[scanner.Status]int)
+	for _, result := range results {
+		for _, header := range result.Headers {
+			counts[header.Status]++
+		}
+	}
+	doc.line(pdfMargin, true, 14, pdfBlack, "Summary")
+	doc.line(pdfMargin, false, 10, pdfBlack, fmt.Sprintf("URLs scanned: %d", len(results)))
+	doc.line(pdfMargin, false, 10, pdfGreen, fmt.Sprintf("Headers present: %d", counts[scanner.StatusPresent]))
+	doc.line(pdfMargin, false, 10, pdfAmber, fmt.Sprintf("Headers misconfigured: %d", counts[scanner.StatusMisconfigured]))
+	doc.line(pdfMargin, false, 10, pdfAmber, fmt.Sprintf("Headers report-only: %d", counts[scanner.StatusReportOnly]))
+	doc.line(pdfMargin, false, 10, pdfAmber, fmt.Sprintf("Deprecated headers: %d", counts[scanner.StatusDeprecated]))
+	doc.line(pdfMargin, false, 10, pdfAmber, fmt.Sprintf("Information disclosure headers: %d", counts[scanner.StatusDisclosure]))
+	doc.line(pdfMargin, false, 10, pdfRed, fmt.Sprintf("Headers missing: %d", counts[scanner.StatusMissing]))
+	doc.space(6)
+	required := scanner.RequiredHeaders()
+	for _, result := range results {
+		valid := 0
+		for _, name := range required {
+			if header, _ := scanner.FindHeader(result.Headers, name); header.Status == scanner.StatusPresent {
+				valid++
+			}
+		}
+		doc.line(pdfMargin+10, false, 10, pdfBlack,
+			fmt.Sprintf("%s - grade %s (%d/100), %d of %d headers correctly configured",
+				result.URL, result.Grade, result.Score, valid, len(required)))
+	}
+
+	// Per-target detail
+	for _, result := range results {
+		doc.space(14)
+		doc.ensureSpace(60)
+		doc.line(pdfMargin, true, 13, pdfBlack, result.URL)
+		doc.line(pdfMargin, true, 11, pdfGradeColor(result.Grade), fmt.Sprintf("Grade %s (%d/100)%s", result.Grade, result.Score, responseNote(result)+retriedNote(result)))
+		if result.TLS != nil {
+			doc.ensureSpace(14)
+			doc.line(pdfMargin, false, 9, pdfGray, "TLS: "+result.TLS.String())
+			if result.TLS.Certificate != nil {
+				doc.ensureSpace(14)
+				doc.line(pdfMargin, false, 9, pdfGray, "Certificate: "+result.TLS.Certificate.String())
+			}
+		}
+		for _, hop := range result.Redirects {
+			doc.ensureSpace(14)
+			doc.line(pdfMargin, false, 9, pdfGray, "Redirect: "+hop.String())
+		}
+		doc.space(2)
+		for _, header := range result.Headers {
+			doc.ensureSpace(14)
+			doc.text(pdfMargin+10, true, 10, pdfBlack, header.Name)
+			status := string(header.Status)
+			if header.Severity != "" {
+				status += " (" + string(header.Severity) + ")"
+			}
+			doc.line(pdfMargin+200, true, 10, pdfStatusColors[header.Status], status)
+			if header.Present {
+				doc.line(pdfMargin+20, false, 8, pdfGray, header.Value)
+			}
+			for _, issue := range header.Issues {
+				doc.line(pdfMargin+20, false, 9, pdfRed, "- "+issue)
+			}
+			for _, warning := range header.Warnings {
+				doc.line(pdfMargin+20, false, 9, pdfAmber, "Warning: "+warning)
+			}
+		}
+		if len(result.Waived) > 0 {
+			doc.space(4)
+			doc.ensureSpace(28)
+			doc.line(pdfMargin+10, true, 10, pdfBlack, "Waived findings")
+		}
+		for _, waived := range result.Waived {
+			doc.ensureSpace(14)
+			doc.line(pdfMargin+20, false, 9, pdfGray,
+				fmt.Sprintf("%s: %s, %s%s", waived.Name, waived.Status, waived.Reason, waivedExpiry(waived)))
+		}
+	}
+
+	return doc.writeTo(w)
+}
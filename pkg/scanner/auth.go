@@ -0,0 +1,64 @@
+package scanner
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Credentials authenticate the requests to a target. Empty fields fall
+// back to the --auth-basic and --auth-bearer defaults.
+type Credentials struct {
+	// Basic is the "user:password" pair for HTTP basic authentication
+	Basic  string
+	Bearer string
+	// Headers are "Name: value" headers for custom schemes such as API
+	// keys, sent after the --header headers
+	Headers []string
+	// NoDefaults sends none of the defaults of the command line, i.e.
+	// --auth-basic, --auth-bearer, --header, --cookie and --cookie-file,
+	// as for the URLs the servers' clients send, which must not collect
+	// the operator's credentials
+	NoDefaults bool
+}
+
+// DefaultAuth holds the credentials of --auth-basic and --auth-bearer, used
+// for the targets that don't set their own
+var DefaultAuth Credentials
+
+// Validate reports credentials that cannot be sent
+func (c Credentials) Validate() error {
+	if c.Basic != "" && c.Bearer != "" {
+		return errors.New("basic and bearer authentication cannot be combined")
+	}
+	if c.Basic != "" && !strings.Contains(c.Basic, ":") {
+		return errors.New("basic authentication must be formatted as user:password")
+	}
+	var headers HeaderFlag
+	for _, header := range c.Headers {
+		if err := headers.Set(header); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setAuth authenticates a request with the target's credentials, or the
+// defaults when the target sets neither basic nor bearer authentication
+// and allows them
+func setAuth(req *http.Request, auth Credentials) {
+	if auth.Basic == "" && auth.Bearer == "" && !auth.NoDefaults {
+		auth.Basic, auth.Bearer = DefaultAuth.Basic, DefaultAuth.Bearer
+	}
+	switch {
+	case auth.Basic != "":
+		user, password, _ := strings.Cut(auth.Basic, ":")
+		req.SetBasicAuth(user, password)
+	case auth.Bearer != "":
+		req.Header.Set("Authorization", "Bearer "+auth.Bearer)
+	}
+	for _, header := range auth.Headers {
+		name, value, _ := strings.Cut(header, ":")
+		req.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+}
@@ -0,0 +1,155 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/net/publicsuffix"
+)
+
+// CAAChecks enables the DNS CAA record check
+var CAAChecks bool
+
+// typeCAA is the DNS record type of CAA, which dnsmessage has no constant for
+const typeCAA dnsmessage.Type = 257
+
+// caaRecord is a DNS CAA record of RFC 8659
+type caaRecord struct {
+	Critical bool
+	Tag      string
+	Value    string
+}
+
+// caaLookup is the outcome of looking up the CAA records of a domain
+type caaLookup struct {
+	Domain  string
+	Records []caaRecord
+	Err     error
+}
+
+var (
+	// caaCache holds the lookups of each host already checked, guarded by
+	// caaMu since targets are scanned concurrently
+	caaCache = make(map[string]caaLookup)
+	caaMu    sync.Mutex
+)
+
+// lookupCAA returns the CAA records that apply to a host. Following RFC
+// 8659 the first domain with records, climbing from the host towards its
+// registrable domain, is authoritative.
+func lookupCAA(ctx context.Context, host string) caaLookup {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	caaMu.Lock()
+	lookup, ok := caaCache[host]
+	caaMu.Unlock()
+	if ok {
+		return lookup
+	}
+
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		registrable = host
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	domain := host
+	for {
+		lookup = caaLookup{Domain: domain}
+		lookup.Records, lookup.Err = queryCAA(ctx, domain)
+		if lookup.Err != nil || len(lookup.Records) > 0 || domain == registrable {
+			break
+		}
+		_, domain, _ = strings.Cut(domain, ".")
+	}
+
+	// Lookups cut short by a timeout or cancellation are tried again
+	if ctx.Err() == nil || lookup.Err == nil {
+		caaMu.Lock()
+		caaCache[host] = lookup
+		caaMu.Unlock()
+	}
+	return lookup
+}
+
+// queryCAA returns the CAA records of a single domain
+func queryCAA(ctx context.Context, domain string) ([]caaRecord, error) {
+	answer, err := dnsQuery(ctx, DNSExchange, domain, typeCAA)
+	if err != nil {
+		return nil, err
+	}
+	switch answer.RCode {
+	case dnsmessage.RCodeSuccess, dnsmessage.RCodeNameError:
+	default:
+		return nil, fmt.Errorf("DNS server answered %s", answer.RCode)
+	}
+	var records []caaRecord
+	for _, resource := range answer.Answers {
+		body, ok := resource.Body.(*dnsmessage.UnknownResource)
+		if !ok || body.Type != typeCAA || len(body.Data) < 2 {
+			continue
+		}
+		tagLength := int(body.Data[1])
+		if len(body.Data) < 2+tagLength {
+			continue
+		}
+		records = append(records, caaRecord{
+			Critical: body.Data[0]&0x80 != 0,
+			Tag:      strings.ToLower(string(body.Data[2 : 2+tagLength])),
+			Value:    string(body.Data[2+tagLength:]),
+		})
+	}
+	return records, nil
+}
+
+// checkCAA reports domains without CAA records, which let any certificate
+// authority issue certificates for them. It reports false for IP addresses,
+// which have no CAA records.
+func checkCAA(ctx context.Context, host string) (HeaderResult, bool) {
+	if net.ParseIP(host) != nil {
+		return HeaderResult{}, false
+	}
+	lookup := lookupCAA(ctx, host)
+	result := HeaderResult{Name: "CAA", Category: categoryDNS, Status: StatusMissing}
+	if lookup.Err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("could not look up CAA records: %v", lookup.Err))
+		return result, true
+	}
+	if len(lookup.Records) == 0 {
+		return result, true
+	}
+
+	var f findings
+	var values []string
+	issuers := 0
+	for _, record := range lookup.Records {
+		values = append(values, strings.TrimSpace(fmt.Sprintf("%s %s", record.Tag, record.Value)))
+		switch record.Tag {
+		case "issue", "issuewild":
+			issuers++
+		case "iodef", "issuemail", "issuevmc", "contactemail", "contactphone":
+		default:
+			if record.Critical {
+				f.issue("the critical %s property is unknown, so CAs must refuse to issue", record.Tag)
+			}
+		}
+	}
+	if issuers == 0 {
+		f.warn("no issue or issuewild property restricts the CAs of %s", lookup.Domain)
+	}
+	result.Present = true
+	result.Status = StatusPresent
+	result.Value = strings.Join(values, "; ")
+	if lookup.Domain != strings.ToLower(strings.TrimSuffix(host, ".")) {
+		result.Value += " (from " + lookup.Domain + ")"
+	}
+	result.Issues, result.Warnings = f.Issues, f.Warnings
+	if len(f.Issues) > 0 {
+		result.Status = StatusMisconfigured
+	}
+	return result, true
+}
@@ -0,0 +1,52 @@
+package scanner
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SensitivePaths are URL path fragments that mark targets as sensitive, in
+// addition to targets tagged "sensitive" in the input file
+var SensitivePaths []string
+
+// isSensitive reports whether the target serves sensitive content that must
+// not be cached
+func isSensitive(t Target) bool {
+	return sensitiveProfile || t.matches("sensitive", SensitivePaths)
+}
+
+// checkCacheControl checks that a sensitive response cannot be stored by
+// browsers or shared caches
+func checkCacheControl(headers http.Header) HeaderResult {
+	result := HeaderResult{Name: "Cache-Control", Status: StatusMissing}
+	if !hasHeader(headers, "Cache-Control") {
+		result.Issues = []string{"sensitive response has no Cache-Control header and may be cached"}
+		return result
+	}
+
+	result.Present = true
+	result.Value = strings.Join(headers.Values("Cache-Control"), ", ")
+	directives := make(map[string]bool)
+	for _, directive := range SplitList(result.Value) {
+		name, _, _ := strings.Cut(directive, "=")
+		directives[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+
+	var f findings
+	switch {
+	case directives["no-store"]:
+	case directives["private"]:
+		f.warn("private still allows the browser to store the response, prefer no-store")
+	default:
+		f.issue("sensitive response can be cached, add no-store")
+	}
+	if directives["public"] {
+		f.issue("public allows shared caches to store the sensitive response")
+	}
+
+	result.Status, result.Issues, result.Warnings = StatusPresent, f.Issues, f.Warnings
+	if len(f.Issues) > 0 {
+		result.Status = StatusMisconfigured
+	}
+	return result
+}
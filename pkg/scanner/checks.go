@@ -0,0 +1,252 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Check is a check of the responses, such as of a security header. The
+// built-in header checks are registered from the start, programs embedding
+// the scanner Register their own, and the command runs external programs
+// with --plugin.
+type Check interface {
+	// ID names the results of the check, like the header names of the
+	// built-in results, e.g. "X-Internal-Policy"
+	ID() string
+	// Description says what the check protects against, e.g. in SARIF
+	Description() string
+	// Severity is the severity of the check failing, unless --severities
+	// overrides it
+	Severity() Severity
+	// Evaluate checks a response and the start of its body, reporting
+	// false when the check doesn't apply to it
+	Evaluate(ctx context.Context, resp *http.Response, body []byte) (HeaderResult, bool)
+}
+
+// headerCheck checks that a required security header is sent with a valid
+// value, or at least its report-only variant
+type headerCheck struct {
+	name        string
+	description string
+	severity    Severity
+}
+
+func (c *headerCheck) ID() string          { return c.name }
+func (c *headerCheck) Description() string { return c.description }
+func (c *headerCheck) Severity() Severity  { return c.severity }
+
+func (c *headerCheck) Evaluate(_ context.Context, resp *http.Response, _ []byte) (HeaderResult, bool) {
+	headers := resp.Header
+	result := HeaderResult{Name: c.name, Status: StatusMissing}
+	reportOnly, hasReportOnly := reportOnlyHeaders[c.name]
+	if hasHeader(headers, c.name) {
+		result.Present = true
+		result.setValues(headers.Values(c.name))
+		var f findings
+		result.Status, f = validateHeader(c.name, headers.Values(c.name)...)
+		result.Issues, result.Warnings = f.Issues, f.Warnings
+	} else if hasReportOnly && hasHeader(headers, reportOnly) {
+		result.Status = StatusReportOnly
+		result.setValues(headers.Values(reportOnly))
+		f := validateReportOnly(c.name, reportOnly, result.Value)
+		result.Issues, result.Warnings = f.Issues, f.Warnings
+	}
+	if legacy, ok := legacyHeaders[c.name]; ok {
+		if hasHeader(headers, legacy) {
+			result.Warnings = append(result.Warnings, "legacy "+legacy+" header is still sent")
+		}
+	}
+	return result, true
+}
+
+// The registry and the disabled checks are process-global, shared by every
+// Scanner of the process, and registryMu guards them so that checks can be
+// registered or toggled while others scan. Toggling them while a scan runs
+// still changes the checks of the URLs it fetches afterwards.
+var registryMu sync.RWMutex
+
+// registry holds the registered checks in the order their results are
+// reported, starting with the built-in header checks
+var registry = []Check{
+	&headerCheck{"Content-Security-Policy", "Content-Security-Policy mitigates cross-site scripting and data injection attacks", SeverityCritical},
+	&headerCheck{"Strict-Transport-Security", "Strict-Transport-Security enforces HTTPS connections", SeverityHigh},
+	&headerCheck{"X-Frame-Options", "X-Frame-Options protects against clickjacking", SeverityHigh},
+	&headerCheck{"X-Content-Type-Options", "X-Content-Type-Options prevents MIME type sniffing", SeverityMedium},
+	&headerCheck{"Referrer-Policy", "Referrer-Policy controls how much referrer information is sent", SeverityMedium},
+	&headerCheck{"Permissions-Policy", "Permissions-Policy restricts access to browser features", SeverityMedium},
+	&headerCheck{"Cross-Origin-Opener-Policy", "Cross-Origin-Opener-Policy isolates the browsing context from cross-origin windows", SeverityMedium},
+	&headerCheck{"Cross-Origin-Embedder-Policy", "Cross-Origin-Embedder-Policy prevents loading cross-origin resources without consent", SeverityInfo},
+	&headerCheck{"Cross-Origin-Resource-Policy", "Cross-Origin-Resource-Policy controls which sites can embed the resource", SeverityInfo},
+	&headerCheck{"X-Permitted-Cross-Domain-Policies", "X-Permitted-Cross-Domain-Policies restricts cross-domain requests from Flash and PDF clients", SeverityInfo},
+}
+
+// disabled holds the lowercased IDs of the checks that don't run.
+// X-Permitted-Cross-Domain-Policies only matters to sites still serving
+// Flash or PDF clients, so it is off unless --cross-domain-policies
+// enables it.
+var disabled = map[string]bool{"x-permitted-cross-domain-policies": true}
+
+// Register adds a check that runs on every response after the header
+// checks. It is meant to be called from init functions or before scanning,
+// and panics when a check of the same ID was already registered.
+func Register(check Check) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if lookupCheck(check.ID()) != nil {
+		panic("scanner: Register called twice for check " + check.ID())
+	}
+	registry = append(registry, check)
+}
+
+// Registered returns the registered checks in registration order, the
+// built-in header checks included, whether they are enabled or not
+func Registered() []Check {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]Check(nil), registry...)
+}
+
+// registered returns the registered check of an ID, or nil
+func registered(id string) Check {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return lookupCheck(id)
+}
+
+// lookupCheck returns the registered check of an ID, or nil. The caller
+// holds registryMu.
+func lookupCheck(id string) Check {
+	for _, check := range registry {
+		if strings.EqualFold(check.ID(), id) {
+			return check
+		}
+	}
+	return nil
+}
+
+// Enable runs the registered check of an ID, reporting false when there is
+// none
+func Enable(id string) bool {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if lookupCheck(id) == nil {
+		return false
+	}
+	delete(disabled, strings.ToLower(id))
+	return true
+}
+
+// Disable stops running the registered check of an ID, reporting false
+// when there is none
+func Disable(id string) bool {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if lookupCheck(id) == nil {
+		return false
+	}
+	disabled[strings.ToLower(id)] = true
+	return true
+}
+
+// Enabled reports whether the check of an ID is registered and runs
+func Enabled(id string) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return lookupCheck(id) != nil && !disabled[strings.ToLower(id)]
+}
+
+// RequireHeader enables the check of a header, registering one for headers
+// without a built-in check, such as those of a policy
+func RequireHeader(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	requireHeader(name)
+}
+
+// requireHeader is RequireHeader for callers holding registryMu
+func requireHeader(name string) Check {
+	check := lookupCheck(name)
+	if check == nil {
+		check = &headerCheck{name: name, severity: SeverityMedium}
+		registry = append(registry, check)
+	}
+	delete(disabled, strings.ToLower(name))
+	return check
+}
+
+// SelectHeaders enables the checks of the given headers and disables the
+// other header checks, as --headers does. The selected checks move to the
+// front of the registry, so their results follow the order of names.
+func SelectHeaders(names []string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	var selected []Check
+	for _, name := range names {
+		if check := requireHeader(name); !slices.Contains(selected, check) {
+			selected = append(selected, check)
+		}
+	}
+	checks := selected
+	for _, check := range registry {
+		if slices.Contains(selected, check) {
+			continue
+		}
+		if _, ok := check.(*headerCheck); ok {
+			disabled[strings.ToLower(check.ID())] = true
+		}
+		checks = append(checks, check)
+	}
+	registry = checks
+}
+
+// RequiredHeaders returns the names of the headers whose checks are enabled,
+// in the order of their results
+func RequiredHeaders() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	var names []string
+	for _, check := range registry {
+		if _, ok := check.(*headerCheck); ok && !disabled[strings.ToLower(check.ID())] {
+			names = append(names, check.ID())
+		}
+	}
+	return names
+}
+
+// IsRequiredHeader reports whether the check of a header is enabled
+func IsRequiredHeader(name string) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	_, ok := lookupCheck(name).(*headerCheck)
+	return ok && !disabled[strings.ToLower(name)]
+}
+
+// checkRegistered runs the enabled checks, naming results that don't set a
+// name after their check
+func checkRegistered(ctx context.Context, resp *http.Response, body []byte) []HeaderResult {
+	// The checks run outside the lock, since plugins take a while
+	registryMu.RLock()
+	var enabled []Check
+	for _, check := range registry {
+		if !disabled[strings.ToLower(check.ID())] {
+			enabled = append(enabled, check)
+		}
+	}
+	registryMu.RUnlock()
+
+	var results []HeaderResult
+	for _, check := range enabled {
+		result, ok := check.Evaluate(ctx, resp, body)
+		if !ok {
+			continue
+		}
+		if result.Name == "" {
+			result.Name = check.ID()
+		}
+		results = append(results, result)
+	}
+	return results
+}
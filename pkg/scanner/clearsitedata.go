@@ -0,0 +1,71 @@
+package scanner
+
+import (
+	"net/http"
+	"strings"
+)
+
+// LogoutPaths are URL path fragments that mark targets as logout endpoints,
+// in addition to targets tagged "logout" in the input file
+var LogoutPaths []string
+
+// clearSiteDataTypes are the data types Clear-Site-Data can clear
+var clearSiteDataTypes = map[string]bool{
+	"cache":             true,
+	"cookies":           true,
+	"storage":           true,
+	"executionContexts": true,
+	"clientHints":       true,
+	"prefetchCache":     true,
+	"prerenderCache":    true,
+	"*":                 true,
+}
+
+// isLogout reports whether the target ends the user's session and should
+// clear the data stored by the site
+func isLogout(t Target) bool {
+	return t.matches("logout", LogoutPaths)
+}
+
+// checkClearSiteData checks that a logout response clears the cookies and
+// storage of the site, so a shared device keeps no trace of the session
+func checkClearSiteData(headers http.Header) HeaderResult {
+	result := HeaderResult{Name: "Clear-Site-Data", Status: StatusMissing}
+	if !hasHeader(headers, "Clear-Site-Data") {
+		result.Issues = []string{"logout response has no Clear-Site-Data header, so session data stays in the browser"}
+		return result
+	}
+
+	result.Present = true
+	result.setValues(headers.Values("Clear-Site-Data"))
+	var f findings
+	cleared := make(map[string]bool)
+	for _, directive := range SplitList(result.Value) {
+		// Types are quoted strings, and browsers ignore bare tokens
+		name := strings.Trim(directive, `"`)
+		switch {
+		case !clearSiteDataTypes[name]:
+			f.warn("unknown type %s is ignored", directive)
+		case directive == name:
+			f.issue("type %s must be quoted as \"%s\" or browsers ignore it", name, name)
+		default:
+			cleared[name] = true
+		}
+	}
+	if !cleared["*"] {
+		for _, name := range []string{"cookies", "storage"} {
+			if !cleared[name] {
+				f.issue("\"%s\" is not cleared on logout", name)
+			}
+		}
+		if !cleared["cache"] {
+			f.warn("\"cache\" is not cleared, so cached pages of the session stay available")
+		}
+	}
+
+	result.Status, result.Issues, result.Warnings = StatusPresent, f.Issues, f.Warnings
+	if len(f.Issues) > 0 {
+		result.Status = StatusMisconfigured
+	}
+	return result
+}
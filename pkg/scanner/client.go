@@ -0,0 +1,31 @@
+//go:build !js
+
+package scanner
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// defaultClient returns a client configured like the command's defaults,
+// offering legacy protocols and weak suites so that servers still accepting
+// them are reported rather than unreachable
+func defaultClient(opts Options) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:             http.ProxyFromEnvironment,
+			ForceAttemptHTTP2: true,
+			DialContext:       DialContext(&net.Dialer{Timeout: 10 * time.Second}),
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: opts.InsecureSkipVerify,
+				MinVersion:         tls.VersionTLS10,
+				CipherSuites:       ScanCipherSuites(),
+			},
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 15 * time.Second,
+		},
+		Timeout: opts.timeout(),
+	}
+}
@@ -0,0 +1,12 @@
+//go:build js
+
+package scanner
+
+import "net/http"
+
+// defaultClient returns a client sending the requests with the fetch API of
+// the browser, which controls TLS, so InsecureSkipVerify has no effect. The
+// transport falls back to fetch only while it has no dialer of its own.
+func defaultClient(opts Options) *http.Client {
+	return &http.Client{Transport: &http.Transport{}, Timeout: opts.timeout()}
+}
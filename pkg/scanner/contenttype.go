@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// charsetTypes are the non-text media types whose encoding browsers guess
+// when no charset is declared
+var charsetTypes = map[string]bool{
+	"application/javascript": true,
+	"application/xhtml+xml":  true,
+	"application/xml":        true,
+}
+
+// checkContentType checks that the response declares its Content-Type with
+// a charset for text, so browsers neither sniff the type nor guess the
+// encoding. The body is sniffed to tell whether an undeclared or generic
+// type hides an HTML page. It reports false when there is nothing to flag.
+func checkContentType(headers http.Header, body []byte) (HeaderResult, bool) {
+	html := len(body) > 0 && strings.HasPrefix(http.DetectContentType(body), "text/html")
+	result := HeaderResult{Name: "Content-Type", Status: StatusMissing}
+
+	if !hasHeader(headers, "Content-Type") {
+		if len(body) == 0 {
+			return result, false
+		}
+		if html {
+			result.Issues = []string{"HTML page without a Content-Type lets browsers sniff the type"}
+		}
+		return result, true
+	}
+
+	result.Present = true
+	result.Value = headers.Get("Content-Type")
+	var f findings
+	mediaType, params, err := mime.ParseMediaType(result.Value)
+	switch {
+	case err != nil:
+		f.issue("invalid Content-Type: %v", err)
+	case mediaType == "application/octet-stream" && html:
+		f.issue("HTML page is served as generic application/octet-stream")
+	case strings.HasPrefix(mediaType, "text/") || charsetTypes[mediaType]:
+		if params["charset"] == "" {
+			f.warn("%s has no charset, so browsers guess the encoding", mediaType)
+		}
+	}
+	if len(f.Issues) == 0 && len(f.Warnings) == 0 {
+		return result, false
+	}
+
+	result.Status = StatusPresent
+	result.Issues, result.Warnings = f.Issues, f.Warnings
+	if len(f.Issues) > 0 {
+		result.Status = StatusMisconfigured
+	}
+	return result, true
+}
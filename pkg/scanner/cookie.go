@@ -0,0 +1,130 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	neturl "net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// CookieFlag collects "name=value" cookies, several of which may be given
+// at once separated by semicolons like a Cookie header
+type CookieFlag struct {
+	RepeatedFlag
+}
+
+func (c *CookieFlag) Set(value string) error {
+	if _, err := http.ParseCookie(value); err != nil {
+		return fmt.Errorf("cookie %q must be formatted as name=value: %v", value, err)
+	}
+	return c.RepeatedFlag.Set(value)
+}
+
+var (
+	// RequestCookies are the --cookie cookies sent with every request
+	RequestCookies CookieFlag
+
+	// fileCookies are the cookies of the --cookie-file, added to the jar of
+	// every request for the domains they belong to
+	fileCookies []fileCookie
+)
+
+// fileCookie is a cookie of a cookie file along with the URL it was set by
+type fileCookie struct {
+	URL    *neturl.URL
+	Cookie *http.Cookie
+}
+
+// LoadCookieFile reads a cookie file in the Netscape format written by
+// curl, wget and browser extensions. Each line holds the domain, whether
+// subdomains are included, the path, whether the cookie is secure, its
+// expiry as a Unix time, its name and its value, separated by tabs. Expired
+// cookies are skipped.
+func LoadCookieFile(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		// curl marks HttpOnly cookies with a prefix on otherwise commented
+		// out lines
+		httpOnly := strings.HasPrefix(line, "#HttpOnly_")
+		line = strings.TrimPrefix(line, "#HttpOnly_")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("line %d: expected 7 tab-separated fields, got %d", number, len(fields))
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid expiry %q", number, fields[4])
+		}
+		if expires != 0 && time.Unix(expires, 0).Before(time.Now()) {
+			continue
+		}
+
+		cookie := &http.Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		if expires != 0 {
+			cookie.Expires = time.Unix(expires, 0)
+		}
+		host := strings.TrimPrefix(fields[0], ".")
+		if strings.EqualFold(fields[1], "TRUE") {
+			cookie.Domain = host
+		}
+		scheme := "http"
+		if cookie.Secure {
+			scheme = "https"
+		}
+		fileCookies = append(fileCookies, fileCookie{
+			URL:    &neturl.URL{Scheme: scheme, Host: host, Path: fields[2]},
+			Cookie: cookie,
+		})
+	}
+	return scanner.Err()
+}
+
+// newCookieJar returns a jar holding the cookie file cookies, which keeps
+// the cookies set along a chain of redirects so that authenticated or
+// consent-gated pages return their real headers. Each request gets its own
+// jar so that the cookies set while scanning one target don't change the
+// responses of the others. Without withFile, the jar starts empty.
+func newCookieJar(withFile bool) http.CookieJar {
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	if !withFile {
+		return jar
+	}
+	for _, c := range fileCookies {
+		jar.SetCookies(c.URL, []*http.Cookie{c.Cookie})
+	}
+	return jar
+}
+
+// setRequestCookies adds the --cookie cookies to a request
+func setRequestCookies(req *http.Request) {
+	for _, value := range RequestCookies.RepeatedFlag {
+		cookies, _ := http.ParseCookie(value)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"net/http"
+	"strings"
+)
+
+// checkCookies inspects every Set-Cookie header and reports cookies missing
+// the Secure, HttpOnly or SameSite attributes, along with cookies that break
+// the rules of the __Secure- and __Host- prefixes. It reports false when no
+// cookies were set.
+func checkCookies(headers http.Header) (HeaderResult, bool) {
+	values := headers.Values("Set-Cookie")
+	if len(values) == 0 {
+		return HeaderResult{}, false
+	}
+
+	var f findings
+	for _, value := range values {
+		cookie, err := http.ParseSetCookie(value)
+		if err != nil {
+			f.issue("invalid cookie %q: %v", value, err)
+			continue
+		}
+		validateCookie(cookie, &f)
+	}
+
+	result := HeaderResult{
+		Name:     "Set-Cookie",
+		Present:  true,
+		Status:   StatusPresent,
+		Value:    strings.Join(values, "\n"),
+		Issues:   f.Issues,
+		Warnings: f.Warnings,
+	}
+	if len(values) > 1 {
+		result.Values = values
+	}
+	if len(f.Issues) > 0 {
+		result.Status = StatusMisconfigured
+	}
+	return result, true
+}
+
+// validateCookie checks the security attributes of a single cookie
+func validateCookie(cookie *http.Cookie, f *findings) {
+	name := cookie.Name
+	if !cookie.Secure {
+		f.issue("cookie %s is missing Secure and can be sent over HTTP", name)
+	}
+	if !cookie.HttpOnly {
+		f.warn("cookie %s is missing HttpOnly and can be read by scripts", name)
+	}
+	// An unset SameSite parses as zero and one without a valid value as the
+	// default mode, both of which leave the browser default in place
+	switch cookie.SameSite {
+	case 0, http.SameSiteDefaultMode:
+		f.warn("cookie %s is missing SameSite", name)
+	case http.SameSiteNoneMode:
+		f.warn("cookie %s uses SameSite=None and is sent on cross-site requests", name)
+	}
+
+	// Browsers reject prefixed cookies that do not meet the prefix rules
+	switch {
+	case strings.HasPrefix(name, "__Host-"):
+		if !cookie.Secure || cookie.Path != "/" || cookie.Domain != "" {
+			f.issue("cookie %s requires Secure, Path=/ and no Domain for the __Host- prefix", name)
+		}
+	case strings.HasPrefix(name, "__Secure-"):
+		if !cookie.Secure {
+			f.issue("cookie %s requires Secure for the __Secure- prefix", name)
+		}
+	}
+}
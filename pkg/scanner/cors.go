@@ -0,0 +1,84 @@
+package scanner
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CORSOrigin is the Origin header sent with each request so that reflected
+// origins can be detected
+var CORSOrigin string
+
+// corsRiskyMethods are methods that should rarely be allowed cross-origin
+var corsRiskyMethods = map[string]bool{
+	"CONNECT": true,
+	"TRACE":   true,
+}
+
+// checkCORS analyzes the Access-Control-Allow-* headers for dangerous
+// combinations. It reports false when the response has no CORS headers.
+func checkCORS(headers http.Header) (HeaderResult, bool) {
+	if !hasHeader(headers, "Access-Control-Allow-Origin") {
+		return HeaderResult{}, false
+	}
+	origin := strings.TrimSpace(headers.Get("Access-Control-Allow-Origin"))
+	credentials := strings.TrimSpace(headers.Get("Access-Control-Allow-Credentials")) == "true"
+
+	var f findings
+	if values := headers.Values("Access-Control-Allow-Origin"); len(values) > 1 {
+		f.warn("header is sent %d times, so browsers reject every cross-origin read", len(values))
+	}
+	switch {
+	case origin == "*" && credentials:
+		f.issue("wildcard origin combined with Access-Control-Allow-Credentials: true")
+	case origin == "*":
+		f.warn("any origin can read responses, which is only safe for public resources")
+	case strings.EqualFold(origin, "null"):
+		f.issue("null origin can be obtained by sandboxed iframes and local files")
+	case CORSOrigin != "" && origin == CORSOrigin && credentials:
+		f.issue("request origin %s is reflected with credentials allowed", CORSOrigin)
+	case CORSOrigin != "" && origin == CORSOrigin:
+		f.warn("request origin %s is reflected", CORSOrigin)
+	case strings.HasPrefix(strings.ToLower(origin), "http://"):
+		f.warn("insecure origin %s is allowed", origin)
+	}
+
+	for _, method := range SplitList(headers.Get("Access-Control-Allow-Methods")) {
+		method = strings.ToUpper(method)
+		switch {
+		case method == "*":
+			f.warn("all methods are allowed")
+		case corsRiskyMethods[method]:
+			f.warn("%s method is allowed", method)
+		}
+	}
+	for _, header := range SplitList(headers.Get("Access-Control-Allow-Headers")) {
+		if header == "*" {
+			f.warn("all request headers are allowed")
+		}
+	}
+
+	result := HeaderResult{
+		Name:     "Access-Control-Allow-Origin",
+		Present:  true,
+		Status:   StatusPresent,
+		Value:    origin,
+		Issues:   f.Issues,
+		Warnings: f.Warnings,
+	}
+	if len(f.Issues) > 0 {
+		result.Status = StatusMisconfigured
+	}
+	return result, true
+}
+
+// SplitList splits a comma-separated header value, trimming each element
+func SplitList(value string) []string {
+	var list []string
+	for _, element := range strings.Split(value, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			list = append(list, element)
+		}
+	}
+	return list
+}
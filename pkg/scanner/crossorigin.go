@@ -0,0 +1,45 @@
+package scanner
+
+import (
+	"strings"
+)
+
+// crossOriginPolicy returns the policy token of a Cross-Origin-* header,
+// dropping parameters such as report-to
+func crossOriginPolicy(value string) string {
+	token, _, _ := strings.Cut(value, ";")
+	return strings.ToLower(strings.TrimSpace(token))
+}
+
+// validateCOOP checks the Cross-Origin-Opener-Policy value
+func validateCOOP(value string, f *findings) {
+	switch policy := crossOriginPolicy(value); policy {
+	case "same-origin", "same-origin-allow-popups", "noopener-allow-popups":
+	case "unsafe-none":
+		f.warn("unsafe-none does not isolate the browsing context from cross-origin windows")
+	default:
+		f.issue("invalid value %q, expected same-origin, same-origin-allow-popups or noopener-allow-popups", policy)
+	}
+}
+
+// validateCOEP checks the Cross-Origin-Embedder-Policy value
+func validateCOEP(value string, f *findings) {
+	switch policy := crossOriginPolicy(value); policy {
+	case "require-corp", "credentialless":
+	case "unsafe-none":
+		f.warn("unsafe-none allows loading cross-origin resources without their consent")
+	default:
+		f.issue("invalid value %q, expected require-corp or credentialless", policy)
+	}
+}
+
+// validateCORP checks the Cross-Origin-Resource-Policy value
+func validateCORP(value string, f *findings) {
+	switch policy := crossOriginPolicy(value); policy {
+	case "same-origin", "same-site":
+	case "cross-origin":
+		f.warn("cross-origin allows any site to embed this resource")
+	default:
+		f.issue("invalid value %q, expected same-origin, same-site or cross-origin", policy)
+	}
+}
@@ -0,0 +1,379 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cspDirective is a single directive of a Content-Security-Policy
+type cspDirective struct {
+	Name    string
+	Sources []string
+}
+
+// cspPolicy is a parsed Content-Security-Policy
+type cspPolicy struct {
+	Directives []cspDirective
+	// Duplicates lists directives that were declared more than once and
+	// therefore ignored by browsers after the first occurrence
+	Duplicates []string
+}
+
+// parseCSP parses a Content-Security-Policy into its directives
+func parseCSP(value string) cspPolicy {
+	var policy cspPolicy
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		if seen[name] {
+			policy.Duplicates = append(policy.Duplicates, name)
+			continue
+		}
+		seen[name] = true
+		policy.Directives = append(policy.Directives, cspDirective{Name: name, Sources: fields[1:]})
+	}
+	return policy
+}
+
+// parseCSPList parses a Content-Security-Policy that may hold several
+// comma-separated policies, as it does when the header is sent more than
+// once. Browsers enforce every policy.
+func parseCSPList(value string) []cspPolicy {
+	var policies []cspPolicy
+	for _, part := range strings.Split(value, ",") {
+		if policy := parseCSP(part); len(policy.Directives) > 0 {
+			policies = append(policies, policy)
+		}
+	}
+	return policies
+}
+
+// directive returns the named directive, if declared
+func (p cspPolicy) directive(name string) (cspDirective, bool) {
+	for _, directive := range p.Directives {
+		if directive.Name == name {
+			return directive, true
+		}
+	}
+	return cspDirective{}, false
+}
+
+// effective returns the directive that applies for name, falling back to
+// default-src as browsers do for fetch directives
+func (p cspPolicy) effective(name string) (cspDirective, bool) {
+	if directive, ok := p.directive(name); ok {
+		return directive, true
+	}
+	return p.directive("default-src")
+}
+
+// has reports whether the directive lists the source, ignoring case
+func (d cspDirective) has(source string) bool {
+	for _, s := range d.Sources {
+		if strings.EqualFold(s, source) {
+			return true
+		}
+	}
+	return false
+}
+
+// usesNonceOrHash reports whether the directive allows scripts by nonce or
+// hash, which makes browsers ignore 'unsafe-inline'
+func (d cspDirective) usesNonceOrHash() bool {
+	for _, s := range d.Sources {
+		lower := strings.ToLower(s)
+		if strings.HasPrefix(lower, "'nonce-") || strings.HasPrefix(lower, "'sha256-") ||
+			strings.HasPrefix(lower, "'sha384-") || strings.HasPrefix(lower, "'sha512-") {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsHosts reports whether the directive allows sources by host,
+// including 'self'
+func (d cspDirective) allowsHosts() bool {
+	for _, s := range d.Sources {
+		if strings.EqualFold(s, "'self'") || sourceHost(s) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcardSources returns the sources of the directive that allow any host
+func (d cspDirective) wildcardSources() []string {
+	var wildcards []string
+	for _, s := range d.Sources {
+		switch strings.ToLower(s) {
+		case "*", "http:", "https:", "http://*", "https://*":
+			wildcards = append(wildcards, s)
+		}
+	}
+	return wildcards
+}
+
+// cspScriptDirectives are the directives that control script execution
+var cspScriptDirectives = []string{"script-src", "script-src-elem", "script-src-attr"}
+
+// cspSeverity ranks CSP findings the way Google's CSP Evaluator does. High
+// severity findings make the policy ineffective and are reported as issues,
+// the others as warnings.
+type cspSeverity string
+
+const (
+	cspHigh   cspSeverity = "high"
+	cspMedium cspSeverity = "medium"
+	cspLow    cspSeverity = "low"
+)
+
+// cspFinding records a CSP finding prefixed with its severity
+func cspFinding(f *findings, severity cspSeverity, format string, args ...interface{}) {
+	message := "[" + string(severity) + "] " + fmt.Sprintf(format, args...)
+	if severity == cspHigh {
+		f.Issues = append(f.Issues, message)
+	} else {
+		f.Warnings = append(f.Warnings, message)
+	}
+}
+
+// cspBypassHosts are hosts that serve JSONP endpoints or AngularJS, either
+// of which lets an attacker run arbitrary script from an allowlisted host
+var cspBypassHosts = []struct {
+	Host   string
+	Serves string
+}{
+	{"accounts.google.com", "JSONP endpoints"},
+	{"ajax.googleapis.com", "AngularJS and JSONP endpoints"},
+	{"cdn.jsdelivr.net", "AngularJS and arbitrary npm packages"},
+	{"cdnjs.cloudflare.com", "AngularJS"},
+	{"code.angularjs.org", "AngularJS"},
+	{"unpkg.com", "AngularJS and arbitrary npm packages"},
+	{"www.google.com", "JSONP endpoints"},
+	{"www.googleapis.com", "JSONP endpoints"},
+	{"www.gstatic.com", "AngularJS"},
+}
+
+// sourceHost returns the host of a host source, dropping the scheme, port
+// and path. Keywords, schemes and nonces have no host.
+func sourceHost(source string) string {
+	if strings.HasPrefix(source, "'") || strings.HasSuffix(source, ":") {
+		return ""
+	}
+	if _, rest, ok := strings.Cut(source, "://"); ok {
+		source = rest
+	}
+	host, _, _ := strings.Cut(source, "/")
+	host, _, _ = strings.Cut(host, ":")
+	return strings.ToLower(host)
+}
+
+// bypassHost reports what a source serves when it allows a known bypass
+// host, including through wildcards such as *.googleapis.com
+func bypassHost(source string) (string, bool) {
+	host := sourceHost(source)
+	if host == "" {
+		return "", false
+	}
+	for _, bypass := range cspBypassHosts {
+		if host == bypass.Host || strings.HasPrefix(host, "*.") && strings.HasSuffix(bypass.Host, host[1:]) {
+			return bypass.Serves, true
+		}
+	}
+	return "", false
+}
+
+// validateCSP evaluates each policy of the Content-Security-Policy, then the
+// directives that matter when any policy declares them
+func validateCSP(value string, f *findings) {
+	validateCSPPolicies(value, f)
+	policies := parseCSPList(value)
+	validateTrustedTypes(policies, f)
+	validateMixedContentDirectives(policies, f)
+}
+
+// validateCSPPolicies evaluates each policy of the Content-Security-Policy.
+// Browsers enforce every policy, so when one has no high severity findings
+// the others can only make it stricter and their issues are reported as
+// warnings.
+func validateCSPPolicies(value string, f *findings) {
+	parts := strings.Split(value, ",")
+	if len(parts) == 1 {
+		validateCSPPolicy(parseCSP(value), f)
+		return
+	}
+
+	results := make([]findings, len(parts))
+	enforced := false
+	for i, part := range parts {
+		validateCSPPolicy(parseCSP(part), &results[i])
+		enforced = enforced || len(results[i].Issues) == 0
+	}
+	for i, policy := range results {
+		label := func(message string) string {
+			severity, rest, _ := strings.Cut(message, "] ")
+			return fmt.Sprintf("%s] policy %d: %s", severity, i+1, rest)
+		}
+		for _, issue := range policy.Issues {
+			if enforced {
+				f.Warnings = append(f.Warnings, label(issue))
+			} else {
+				f.Issues = append(f.Issues, label(issue))
+			}
+		}
+		for _, warning := range policy.Warnings {
+			f.Warnings = append(f.Warnings, label(warning))
+		}
+	}
+}
+
+// validateTrustedTypes reports whether any policy enforces Trusted Types,
+// which protect the DOM sinks that DOM-based cross-site scripting abuses.
+// Few sites deploy them, so their absence is a low severity finding.
+func validateTrustedTypes(policies []cspPolicy, f *findings) {
+	required := false
+	for _, policy := range policies {
+		if directive, ok := policy.directive("require-trusted-types-for"); ok && directive.has("'script'") {
+			required = true
+		}
+		if directive, ok := policy.directive("trusted-types"); ok {
+			if directive.has("*") {
+				cspFinding(f, cspLow, "trusted-types: * allows any policy name to create trusted values")
+			}
+			if directive.has("'allow-duplicates'") {
+				cspFinding(f, cspLow, "trusted-types: 'allow-duplicates' lets injected code recreate an allowed policy")
+			}
+		}
+	}
+	if !required {
+		cspFinding(f, cspLow, "require-trusted-types-for 'script' is missing, so DOM sinks are not protected by Trusted Types")
+	}
+}
+
+// validateMixedContentDirectives warns about block-all-mixed-content, which
+// is deprecated now that browsers block active mixed content and upgrade
+// passive content themselves
+func validateMixedContentDirectives(policies []cspPolicy, f *findings) {
+	upgrade, block := false, false
+	for _, policy := range policies {
+		_, ok := policy.directive("upgrade-insecure-requests")
+		upgrade = upgrade || ok
+		_, ok = policy.directive("block-all-mixed-content")
+		block = block || ok
+	}
+	switch {
+	case block && upgrade:
+		cspFinding(f, cspLow, "block-all-mixed-content is deprecated and has no effect alongside upgrade-insecure-requests")
+	case block:
+		cspFinding(f, cspLow, "block-all-mixed-content is deprecated, use upgrade-insecure-requests instead")
+	}
+}
+
+// validateCSPPolicy evaluates a single policy for the directives and
+// sources that allow cross-site scripting despite the policy, ranking each
+// finding by severity
+func validateCSPPolicy(policy cspPolicy, f *findings) {
+	if len(policy.Directives) == 0 {
+		cspFinding(f, cspHigh, "policy is empty")
+		return
+	}
+	for _, name := range policy.Duplicates {
+		cspFinding(f, cspLow, "%s: duplicate directive is ignored", name)
+	}
+
+	if _, ok := policy.directive("default-src"); !ok {
+		cspFinding(f, cspMedium, "default-src is missing, so undeclared fetch directives are unrestricted")
+	}
+
+	// Script sources fall back to default-src, so check whichever applies
+	checked := make(map[string]bool)
+	nonceOrHash := false
+	for _, name := range cspScriptDirectives {
+		directive, ok := policy.effective(name)
+		if !ok || checked[directive.Name] {
+			continue
+		}
+		checked[directive.Name] = true
+		strictDynamic := directive.has("'strict-dynamic'")
+		nonceOrHash = nonceOrHash || directive.usesNonceOrHash() || strictDynamic
+		if directive.has("'unsafe-inline'") && !directive.usesNonceOrHash() && !strictDynamic {
+			cspFinding(f, cspHigh, "%s: 'unsafe-inline' allows inline scripts", directive.Name)
+		}
+		if !directive.usesNonceOrHash() && !strictDynamic && directive.allowsHosts() {
+			cspFinding(f, cspLow, "%s: relies on a host allowlist rather than nonces or hashes, which is easier to bypass", directive.Name)
+		}
+		if directive.has("'unsafe-eval'") {
+			cspFinding(f, cspMedium, "%s: 'unsafe-eval' allows eval() and similar string-to-code functions", directive.Name)
+		}
+		if directive.has("data:") {
+			cspFinding(f, cspHigh, "%s: data: allows scripts from data URIs", directive.Name)
+		}
+		for _, source := range directive.wildcardSources() {
+			cspFinding(f, cspHigh, "%s: %s allows scripts from any host", directive.Name, source)
+		}
+		for _, source := range directive.Sources {
+			lower := strings.ToLower(source)
+			if strings.HasPrefix(lower, "http://") {
+				cspFinding(f, cspMedium, "%s: %s allows scripts over plain HTTP", directive.Name, source)
+			}
+			if nonce, ok := strings.CutPrefix(lower, "'nonce-"); ok && len(strings.TrimSuffix(nonce, "'")) < 8 {
+				cspFinding(f, cspMedium, "%s: nonce %s is shorter than 8 characters and can be guessed", directive.Name, source)
+			}
+			// Browsers that support 'strict-dynamic' ignore the allowlist
+			if serves, ok := bypassHost(source); ok && !strictDynamic {
+				cspFinding(f, cspHigh, "%s: %s serves %s, which can bypass the policy", directive.Name, source, serves)
+			}
+		}
+	}
+	if _, ok := policy.effective("script-src"); !ok {
+		cspFinding(f, cspHigh, "script-src is missing and no default-src applies, so scripts are unrestricted")
+	}
+
+	// Plugins can run scripts too, so object-src should be 'none'. It is
+	// evaluated even when it falls back to the default-src that script-src
+	// already checked, since that allows more than 'none'.
+	if object, ok := policy.effective("object-src"); !ok {
+		cspFinding(f, cspHigh, "object-src is missing, so plugins can execute scripts, set object-src 'none'")
+	} else {
+		checked[object.Name] = true
+		switch {
+		case object.has("data:") || len(object.wildcardSources()) > 0:
+			cspFinding(f, cspHigh, "%s: allows plugins from any host, set object-src 'none'", object.Name)
+		case !(len(object.Sources) == 1 && object.has("'none'")):
+			cspFinding(f, cspMedium, "%s: allows plugins that may execute scripts, set object-src 'none'", object.Name)
+		}
+	}
+
+	// base-uri and form-action do not fall back to default-src
+	if base, ok := policy.directive("base-uri"); !ok {
+		if nonceOrHash {
+			cspFinding(f, cspHigh, "base-uri is missing, so an injected <base> tag can load nonce or hash allowed scripts from another host")
+		}
+	} else if len(base.wildcardSources()) > 0 {
+		cspFinding(f, cspMedium, "base-uri: allows any host, set base-uri 'none' or 'self'")
+	}
+	checked["base-uri"] = true
+	if form, ok := policy.directive("form-action"); !ok {
+		cspFinding(f, cspLow, "form-action is missing, so injected forms can submit to any host")
+	} else if len(form.wildcardSources()) > 0 {
+		cspFinding(f, cspMedium, "form-action: allows forms to submit to any host")
+	}
+	checked["form-action"] = true
+
+	// Other directives can only weaken the policy, so report as warnings
+	for _, directive := range policy.Directives {
+		if checked[directive.Name] {
+			continue
+		}
+		if directive.has("'unsafe-inline'") {
+			cspFinding(f, cspLow, "%s: 'unsafe-inline' is allowed", directive.Name)
+		}
+		for _, source := range directive.wildcardSources() {
+			cspFinding(f, cspLow, "%s: %s allows any host", directive.Name, source)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package scanner
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+	cbasn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+var (
+	// CTChecks enables the Certificate Transparency check
+	CTChecks bool
+
+	// ctLogs are the logs of the --ct-logs list by log ID, against which
+	// SCT signatures are verified. Without a list SCTs are only counted.
+	ctLogs map[[32]byte]ctLog
+)
+
+// oidSCTList is the X.509 extension embedding SCTs in a certificate
+var oidSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// ctLog is a Certificate Transparency log of the log list
+type ctLog struct {
+	Description string
+	Key         crypto.PublicKey
+}
+
+// LoadCTLogs reads a log list in the v3 JSON format Google and Apple
+// publish, e.g. https://www.gstatic.com/ct/log_list/v3/log_list.json
+func LoadCTLogs(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	var list struct {
+		Operators []struct {
+			Logs []struct {
+				Description string `json:"description"`
+				LogID       []byte `json:"log_id"`
+				Key         []byte `json:"key"`
+			} `json:"logs"`
+		} `json:"operators"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	ctLogs = make(map[[32]byte]ctLog)
+	for _, operator := range list.Operators {
+		for _, log := range operator.Logs {
+			key, err := x509.ParsePKIXPublicKey(log.Key)
+			if err != nil {
+				return fmt.Errorf("log %q: %v", log.Description, err)
+			}
+			ctLogs[sha256.Sum256(log.Key)] = ctLog{Description: log.Description, Key: key}
+		}
+	}
+	return nil
+}
+
+// sct is a Signed Certificate Timestamp, with the data its log signed
+type sct struct {
+	LogID     [32]byte
+	Timestamp time.Time
+	Source    string
+	signed    []byte
+	hash      uint8
+	signature []byte
+}
+
+// parseSCT parses a serialized SCT of RFC 6962, whose signature covers the
+// given entry
+func parseSCT(data []byte, source string, entryType uint16, entry []byte) (sct, error) {
+	s := cryptobyte.String(data)
+	var version, signatureAlgorithm uint8
+	var logID []byte
+	var timestamp uint64
+	var extensions, signature cryptobyte.String
+	var result sct
+	if !s.ReadUint8(&version) || !s.ReadBytes(&logID, 32) || !s.ReadUint64(&timestamp) ||
+		!s.ReadUint16LengthPrefixed(&extensions) || !s.ReadUint8(&result.hash) ||
+		!s.ReadUint8(&signatureAlgorithm) || !s.ReadUint16LengthPrefixed(&signature) || !s.Empty() {
+		return sct{}, errors.New("malformed SCT")
+	}
+	if version != 0 {
+		return sct{}, fmt.Errorf("unsupported SCT version %d", version)
+	}
+	copy(result.LogID[:], logID)
+	result.Timestamp = time.UnixMilli(int64(timestamp))
+	result.Source = source
+	result.signature = signature
+
+	var b cryptobyte.Builder
+	b.AddUint8(0) // version
+	b.AddUint8(0) // certificate_timestamp
+	b.AddUint64(timestamp)
+	b.AddUint16(entryType)
+	b.AddBytes(entry)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(extensions) })
+	result.signed = b.BytesOrPanic()
+	return result, nil
+}
+
+// verify checks the signature of the SCT with the key of its log
+func (s sct) verify(key crypto.PublicKey) error {
+	if s.hash != 4 {
+		return fmt.Errorf("unsupported hash algorithm %d", s.hash)
+	}
+	digest := sha256.Sum256(s.signed)
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], s.signature) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], s.signature)
+	}
+	return fmt.Errorf("unsupported key type %T", key)
+}
+
+// collectSCTs returns the SCTs sent along with the certificate in the TLS
+// handshake and those embedded in it, which can only be parsed when the
+// issuer is known
+func collectSCTs(leaf, issuer *x509.Certificate, handshake [][]byte) ([]sct, []error) {
+	var scts []sct
+	var errs []error
+
+	// SCTs of the TLS extension cover the certificate itself
+	var x509Entry cryptobyte.Builder
+	x509Entry.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(leaf.Raw) })
+	for _, data := range handshake {
+		s, err := parseSCT(data, "TLS extension", 0, x509Entry.BytesOrPanic())
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		scts = append(scts, s)
+	}
+
+	var list []byte
+	for _, extension := range leaf.Extensions {
+		if extension.Id.Equal(oidSCTList) {
+			list = extension.Value
+		}
+	}
+	if list == nil {
+		return scts, errs
+	}
+	if issuer == nil {
+		return scts, append(errs, errors.New("embedded SCTs cannot be checked without the issuing certificate"))
+	}
+
+	// Embedded SCTs cover the precertificate, whose TBSCertificate is the
+	// certificate's without the SCT list, bound to the issuer's key
+	tbs, err := precertTBS(leaf.RawTBSCertificate)
+	if err != nil {
+		return scts, append(errs, err)
+	}
+	issuerKeyHash := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+	var precertEntry cryptobyte.Builder
+	precertEntry.AddBytes(issuerKeyHash[:])
+	precertEntry.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(tbs) })
+
+	value := cryptobyte.String(list)
+	var octets, entries cryptobyte.String
+	if !value.ReadASN1(&octets, cbasn1.OCTET_STRING) || !octets.ReadUint16LengthPrefixed(&entries) {
+		return scts, append(errs, errors.New("malformed embedded SCT list"))
+	}
+	for !entries.Empty() {
+		var data cryptobyte.String
+		if !entries.ReadUint16LengthPrefixed(&data) {
+			return scts, append(errs, errors.New("malformed embedded SCT list"))
+		}
+		s, err := parseSCT(data, "embedded", 1, precertEntry.BytesOrPanic())
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		scts = append(scts, s)
+	}
+	return scts, errs
+}
+
+// precertTBS removes the SCT list extension from a DER TBSCertificate
+func precertTBS(raw []byte) ([]byte, error) {
+	input := cryptobyte.String(raw)
+	var tbs cryptobyte.String
+	if !input.ReadASN1(&tbs, cbasn1.SEQUENCE) {
+		return nil, errors.New("malformed TBSCertificate")
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		for !tbs.Empty() {
+			var element cryptobyte.String
+			var tag cbasn1.Tag
+			if !tbs.ReadAnyASN1Element(&element, &tag) {
+				b.SetError(errors.New("malformed TBSCertificate"))
+				return
+			}
+			extensionsTag := cbasn1.Tag(3).ContextSpecific().Constructed()
+			if tag != extensionsTag {
+				b.AddBytes(element)
+				continue
+			}
+			var wrapper, extensions cryptobyte.String
+			if !element.ReadASN1(&wrapper, extensionsTag) || !wrapper.ReadASN1(&extensions, cbasn1.SEQUENCE) {
+				b.SetError(errors.New("malformed extensions"))
+				return
+			}
+			b.AddASN1(extensionsTag, func(b *cryptobyte.Builder) {
+				b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+					for !extensions.Empty() {
+						var extension, body cryptobyte.String
+						var id asn1.ObjectIdentifier
+						if !extensions.ReadASN1Element(&extension, cbasn1.SEQUENCE) {
+							b.SetError(errors.New("malformed extension"))
+							return
+						}
+						element := extension
+						if element.ReadASN1(&body, cbasn1.SEQUENCE) && body.ReadASN1ObjectIdentifier(&id) && id.Equal(oidSCTList) {
+							continue
+						}
+						b.AddBytes(extension)
+					}
+				})
+			})
+		}
+	})
+	return b.Bytes()
+}
+
+// checkCT reports certificates without Signed Certificate Timestamps, the
+// evidence that they were logged in Certificate Transparency that browsers
+// require of public certificates. With --ct-logs each SCT's signature is
+// verified against its log. It reports false when the response wasn't
+// served over TLS.
+func checkCT(resp *http.Response) (HeaderResult, bool) {
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		return HeaderResult{}, false
+	}
+	leaf := resp.TLS.PeerCertificates[0]
+	var issuer *x509.Certificate
+	switch {
+	case len(resp.TLS.VerifiedChains) > 0 && len(resp.TLS.VerifiedChains[0]) > 1:
+		issuer = resp.TLS.VerifiedChains[0][1]
+	case len(resp.TLS.PeerCertificates) > 1:
+		issuer = resp.TLS.PeerCertificates[1]
+	}
+
+	var f findings
+	scts, errs := collectSCTs(leaf, issuer, resp.TLS.SignedCertificateTimestamps)
+	for _, err := range errs {
+		f.warn("%v", err)
+	}
+	result := HeaderResult{Name: "Certificate-Transparency", Category: categoryTransport}
+	if len(scts) == 0 {
+		result.Status = StatusMissing
+		result.Warnings = f.Warnings
+		return result, true
+	}
+
+	valid := 0
+	for _, s := range scts {
+		if ctLogs == nil {
+			continue
+		}
+		log, ok := ctLogs[s.LogID]
+		if !ok {
+			f.warn("%s SCT from an unknown log", s.Source)
+			continue
+		}
+		if err := s.verify(log.Key); err != nil {
+			f.issue("%s SCT from %s does not verify: %v", s.Source, log.Description, err)
+			continue
+		}
+		if s.Timestamp.After(time.Now()) {
+			f.issue("%s SCT from %s is timestamped in the future", s.Source, log.Description)
+			continue
+		}
+		valid++
+	}
+
+	result.Present = true
+	result.Status = StatusPresent
+	if ctLogs == nil {
+		result.Value = fmt.Sprintf("%d SCTs, not verified without --ct-logs", len(scts))
+	} else {
+		result.Value = fmt.Sprintf("%d of %d SCTs verified", valid, len(scts))
+		if valid < 2 && len(f.Issues) == 0 {
+			f.warn("only %d SCTs verify, browsers expect at least 2 from distinct logs", valid)
+		}
+	}
+	result.Issues, result.Warnings = f.Issues, f.Warnings
+	if len(f.Issues) > 0 {
+		result.Status = StatusMisconfigured
+	}
+	return result, true
+}
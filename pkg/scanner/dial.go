@@ -0,0 +1,106 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// IPNetwork restricts connections to an address family, "tcp4" for -4 or
+// "tcp6" for -6, or is empty to use either
+var IPNetwork string
+
+// ResolveFlag collects curl-style "host:port:address" overrides, where the
+// address may be a comma-separated list and IPv6 addresses are bracketed
+type ResolveFlag struct {
+	RepeatedFlag
+	// addrs maps each lower-case "host:port" to its addresses
+	addrs map[string][]string
+}
+
+func (r *ResolveFlag) Set(value string) error {
+	host, rest, ok1 := strings.Cut(value, ":")
+	port, list, ok2 := strings.Cut(rest, ":")
+	if !ok1 || !ok2 || host == "" || port == "" || list == "" {
+		return fmt.Errorf("resolve %q must be formatted as host:port:address", value)
+	}
+	var addrs []string
+	for _, addr := range strings.Split(list, ",") {
+		addr = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(addr), "["), "]")
+		if net.ParseIP(addr) == nil {
+			return fmt.Errorf("resolve %q: %q is not an IP address", value, addr)
+		}
+		addrs = append(addrs, addr)
+	}
+	if r.addrs == nil {
+		r.addrs = make(map[string][]string)
+	}
+	key := strings.ToLower(net.JoinHostPort(host, port))
+	r.addrs[key] = append(r.addrs[key], addrs...)
+	return r.RepeatedFlag.Set(value)
+}
+
+// ResolveOverrides are the --resolve addresses used instead of resolving
+// their hosts, so origins can be scanned by IP while keeping the Host
+// header and SNI of their URL
+var ResolveOverrides ResolveFlag
+
+// DialContext dials with the dialer, restricting TCP connections to the
+// address family selected by -4 or -6. Hosts with --resolve overrides
+// connect to their addresses, and others are resolved with LookupHost when
+// it is set.
+func DialContext(dialer *net.Dialer) func(context.Context, string, string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		if IPNetwork != "" && network == "tcp" {
+			network = IPNetwork
+		}
+		host, port, err := net.SplitHostPort(address)
+		if err != nil || net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, address)
+		}
+		addrs, ok := ResolveOverrides.addrs[strings.ToLower(address)]
+		if !ok {
+			if LookupHost == nil {
+				return dialer.DialContext(ctx, network, address)
+			}
+			if addrs, err = LookupHost(ctx, host); err != nil {
+				return nil, err
+			}
+		}
+		// Like the system resolver, try each address in turn
+		var lastErr error
+		for _, addr := range addrs {
+			if family := ipFamily(addr); (network == "tcp4" && family != "IPv4") || (network == "tcp6" && family != "IPv6") {
+				continue
+			}
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr, port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		if lastErr == nil {
+			lastErr = &net.DNSError{Err: "no suitable address found", Name: host}
+		}
+		return nil, lastErr
+	}
+}
+
+// ipFamily returns whether a "host:port" address is IPv4 or IPv6, or an
+// empty string when it isn't an IP address
+func ipFamily(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return "IPv4"
+	default:
+		return "IPv6"
+	}
+}
@@ -0,0 +1,243 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+var (
+	// LookupHost resolves the hosts DialContext connects to, or is nil to
+	// use the system resolver
+	LookupHost func(ctx context.Context, host string) ([]string, error)
+
+	// DNSExchange sends the queries of the DNS record checks, such as CAA,
+	// that the system resolver can't look up. It queries the first
+	// nameserver of /etc/resolv.conf unless --dns or --doh replace it.
+	DNSExchange DNSExchanger = systemExchange
+)
+
+// systemExchange sends a query to the first nameserver of /etc/resolv.conf
+// over UDP
+func systemExchange(ctx context.Context, query []byte) ([]byte, error) {
+	server, err := SystemNameserver()
+	if err != nil {
+		return nil, err
+	}
+	return UDPExchanger(server, &net.Dialer{Timeout: 10 * time.Second})(ctx, query)
+}
+
+// DNSExchanger sends a packed DNS query and returns the packed answer
+type DNSExchanger func(ctx context.Context, query []byte) ([]byte, error)
+
+// dnsAddress returns the address of a DNS server given as a host with an
+// optional port that defaults to 53
+func dnsAddress(address string) string {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return net.JoinHostPort(address, "53")
+	}
+	return address
+}
+
+// SystemNameserver returns the first nameserver of /etc/resolv.conf
+func SystemNameserver() (string, error) {
+	data, err := os.ReadFile("/etc/resolv.conf")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return fields[1], nil
+		}
+	}
+	return "", errors.New("no nameserver in /etc/resolv.conf")
+}
+
+// DNSResolver returns a resolver querying the DNS server at address
+func DNSResolver(address string, dialer *net.Dialer) *net.Resolver {
+	address = dnsAddress(address)
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, address)
+		},
+	}
+}
+
+// UDPExchanger returns an exchanger querying the DNS server at address over
+// UDP, retrying over TCP when the answer is truncated
+func UDPExchanger(address string, dialer *net.Dialer) DNSExchanger {
+	address = dnsAddress(address)
+	return func(ctx context.Context, query []byte) ([]byte, error) {
+		// Unlike DNS-over-HTTPS, plain DNS needs a random ID so that
+		// spoofed answers are less likely to be accepted
+		query = append([]byte(nil), query...)
+		id := uint16(rand.Uint32())
+		query[0], query[1] = byte(id>>8), byte(id)
+
+		conn, err := dialer.DialContext(ctx, "udp", address)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		answer := make([]byte, 4096)
+		n, err := conn.Read(answer)
+		if err != nil {
+			return nil, err
+		}
+		var parser dnsmessage.Parser
+		header, err := parser.Start(answer[:n])
+		if err != nil {
+			return nil, err
+		}
+		if header.ID != id {
+			return nil, errors.New("DNS answer does not match the query")
+		}
+		if !header.Truncated {
+			return answer[:n], nil
+		}
+
+		tcp, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return nil, err
+		}
+		defer tcp.Close()
+		if deadline, ok := ctx.Deadline(); ok {
+			tcp.SetDeadline(deadline)
+		}
+		if _, err := tcp.Write(append([]byte{byte(len(query) >> 8), byte(len(query))}, query...)); err != nil {
+			return nil, err
+		}
+		var length [2]byte
+		if _, err := io.ReadFull(tcp, length[:]); err != nil {
+			return nil, err
+		}
+		answer = make([]byte, int(length[0])<<8|int(length[1]))
+		if _, err := io.ReadFull(tcp, answer); err != nil {
+			return nil, err
+		}
+		return answer, nil
+	}
+}
+
+// DoHExchanger returns an exchanger querying a DNS-over-HTTPS server, such
+// as https://cloudflare-dns.com/dns-query, with RFC 8484 POST requests. The
+// server's own name is resolved by the system resolver. tlsConfig should be
+// the resolver's own rather than that of the scans, which accepts legacy
+// protocols and weak suites.
+func DoHExchanger(url string, tlsConfig *tls.Config, timeout time.Duration) DNSExchanger {
+	dohClient := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, ForceAttemptHTTP2: true},
+		Timeout:   timeout,
+	}
+	return func(ctx context.Context, query []byte) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(query))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/dns-message")
+		req.Header.Set("Accept", "application/dns-message")
+		resp, err := dohClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("DNS-over-HTTPS server returned %s", resp.Status)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	}
+}
+
+// DoHResolver returns a lookup function resolving hosts with the queries
+// of a DNS-over-HTTPS exchanger for the server at url
+func DoHResolver(exchange DNSExchanger, url string) func(context.Context, string) ([]string, error) {
+	return func(ctx context.Context, host string) ([]string, error) {
+		var addrs []string
+		var lastErr error
+		for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+			if (qtype == dnsmessage.TypeA && IPNetwork == "tcp6") || (qtype == dnsmessage.TypeAAAA && IPNetwork == "tcp4") {
+				continue
+			}
+			answer, err := dnsQuery(ctx, exchange, host, qtype)
+			if err == nil && answer.RCode != dnsmessage.RCodeSuccess {
+				err = fmt.Errorf("DNS-over-HTTPS server answered %s", answer.RCode)
+			}
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			for _, record := range answer.Answers {
+				switch r := record.Body.(type) {
+				case *dnsmessage.AResource:
+					addrs = append(addrs, net.IP(r.A[:]).String())
+				case *dnsmessage.AAAAResource:
+					addrs = append(addrs, net.IP(r.AAAA[:]).String())
+				}
+			}
+		}
+		if len(addrs) == 0 {
+			if lastErr == nil {
+				lastErr = errors.New("no such host")
+			}
+			return nil, &net.DNSError{Err: lastErr.Error(), Name: host, Server: url}
+		}
+		return addrs, nil
+	}
+}
+
+// dnsQuery sends a single query for the records of a type and returns the
+// answer, whose RCode the caller checks
+func dnsQuery(ctx context.Context, exchange DNSExchanger, host string, qtype dnsmessage.Type) (*dnsmessage.Message, error) {
+	name, err := dnsmessage.NewName(dnsName(host))
+	if err != nil {
+		return nil, err
+	}
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+	if exchange == nil {
+		return nil, errors.New("no DNS server to query")
+	}
+	body, err := exchange(ctx, packed)
+	if err != nil {
+		return nil, err
+	}
+
+	var answer dnsmessage.Message
+	if err := answer.Unpack(body); err != nil {
+		return nil, err
+	}
+	return &answer, nil
+}
+
+// dnsName returns the fully qualified form of a host name
+func dnsName(host string) string {
+	if len(host) > 0 && host[len(host)-1] == '.' {
+		return host
+	}
+	return host + "."
+}
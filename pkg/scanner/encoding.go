@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// AcceptEncoding is the Accept-Encoding sent with every request, or empty
+// to let the transport ask for gzip and decompress it transparently
+var AcceptEncoding string
+
+// decodeBody returns a reader of the decoded response body, or nil when
+// its encoding can't be decoded, in which case the body isn't inspected.
+// The Content-Encoding header the transport removes when it decompresses
+// gzip itself is restored, so results report what the server sent.
+func decodeBody(resp *http.Response) (io.Reader, error) {
+	if resp.Uncompressed {
+		resp.Header.Set("Content-Encoding", "gzip")
+		return resp.Body, nil
+	}
+
+	var reader io.Reader = resp.Body
+	encodings := strings.Split(resp.Header.Get("Content-Encoding"), ",")
+	// Encodings are listed in the order they were applied
+	for i := len(encodings) - 1; i >= 0; i-- {
+		switch encoding := strings.ToLower(strings.TrimSpace(encodings[i])); encoding {
+		case "", "identity":
+		case "gzip", "x-gzip":
+			gz, err := gzip.NewReader(reader)
+			if errors.Is(err, io.EOF) {
+				// Bodiless responses such as HEAD ones
+				return bytes.NewReader(nil), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			reader = gz
+		case "deflate":
+			var err error
+			if reader, err = newDeflateReader(reader); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, nil
+		}
+	}
+	return reader, nil
+}
+
+// newDeflateReader decodes an HTTP deflate body, which is zlib-wrapped as
+// RFC 9110 requires and servers send, falling back to the raw deflate some
+// old servers send instead
+func newDeflateReader(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(2)
+	if len(header) == 0 && errors.Is(err, io.EOF) {
+		// Bodiless responses such as HEAD ones
+		return bytes.NewReader(nil), nil
+	}
+	// A zlib header names the deflate method and is a multiple of 31
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
@@ -0,0 +1,161 @@
+package scanner
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+)
+
+// expressionRule is a policy rule written as a CEL expression over the
+// response, which must evaluate to true
+type expressionRule struct {
+	// Header is the result the finding is reported on
+	Header string `yaml:"header"`
+	// Expr is the CEL expression, e.g.
+	// hsts.max_age >= 31536000 && hsts.include_subdomains
+	Expr string `yaml:"expr"`
+	// Message describes the violation, defaulting to the expression
+	Message string `yaml:"message"`
+	// Warn reports violations as warnings instead of issues
+	Warn bool `yaml:"warn"`
+
+	program cel.Program
+}
+
+// expressionEnv declares the variables available to expressions: the URL,
+// the status code, the headers keyed by lower-case name with repeated values
+// combined, the parsed HSTS policy and the first CSP policy
+func expressionEnv() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("url", cel.StringType),
+		cel.Variable("status", cel.IntType),
+		cel.Variable("headers", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("hsts", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("csp", cel.MapType(cel.StringType, cel.ListType(cel.StringType))),
+	)
+}
+
+// compileExpressions compiles the expressions of the rules, which must
+// evaluate to a bool, or a dynamic value checked when evaluated
+func compileExpressions(rules []expressionRule) error {
+	env, err := expressionEnv()
+	if err != nil {
+		return err
+	}
+	for i, rule := range rules {
+		if rule.Header == "" || rule.Expr == "" {
+			return fmt.Errorf("every expression needs a header and an expr")
+		}
+		ast, issues := env.Compile(rule.Expr)
+		if issues != nil && issues.Err() != nil {
+			return fmt.Errorf("%s: %v", rule.Expr, issues.Err())
+		}
+		if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+			return fmt.Errorf("%s: must evaluate to a bool, not %s", rule.Expr, ast.OutputType())
+		}
+		if rules[i].program, err = env.Program(ast); err != nil {
+			return fmt.Errorf("%s: %v", rule.Expr, err)
+		}
+		rules[i].Header = http.CanonicalHeaderKey(rule.Header)
+	}
+	return nil
+}
+
+// expressionVars returns the values of the expression variables for a
+// response
+func expressionVars(resp *http.Response) map[string]interface{} {
+	headers := make(map[string]string)
+	for name, values := range resp.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ", ")
+	}
+
+	// Without the header the policy is all zero values
+	var policy hstsPolicy
+	if values := resp.Header.Values("Strict-Transport-Security"); len(values) > 0 {
+		var ignored findings
+		policy = parseHSTS(values[0], &ignored)
+	}
+	hsts := map[string]interface{}{
+		"max_age":            policy.MaxAge,
+		"include_subdomains": policy.IncludeSubDomains,
+		"preload":            policy.Preload,
+	}
+
+	csp := make(map[string][]string)
+	if policies := parseCSPList(headers["content-security-policy"]); len(policies) > 0 {
+		for _, directive := range policies[0].Directives {
+			csp[directive.Name] = directive.Sources
+		}
+	}
+
+	url := ""
+	if resp.Request != nil {
+		url = resp.Request.URL.String()
+	}
+	return map[string]interface{}{
+		"url":     url,
+		"status":  resp.StatusCode,
+		"headers": headers,
+		"hsts":    hsts,
+		"csp":     csp,
+	}
+}
+
+// checkExpressions evaluates the expression rules against the response and
+// reports each violation on the rule's header, adding a result for headers
+// that have none
+func checkExpressions(rules []expressionRule, resp *http.Response, results []HeaderResult) []HeaderResult {
+	if len(rules) == 0 {
+		return results
+	}
+	vars := expressionVars(resp)
+	for _, rule := range rules {
+		var f findings
+		out, _, err := rule.program.Eval(vars)
+		passed, isBool := false, false
+		if err == nil {
+			passed, isBool = out.Value().(bool)
+		}
+		switch {
+		case err != nil:
+			f.warn("policy expression %s could not be evaluated: %v", rule.Expr, err)
+		case !isBool:
+			f.warn("policy expression %s does not evaluate to a bool", rule.Expr)
+		case passed:
+			continue
+		case rule.Message != "" && rule.Warn:
+			f.warn("%s", rule.Message)
+		case rule.Message != "":
+			f.issue("%s", rule.Message)
+		case rule.Warn:
+			f.warn("policy expression %s is false", rule.Expr)
+		default:
+			f.issue("policy expression %s is false", rule.Expr)
+		}
+
+		index := -1
+		for i := range results {
+			if results[i].Name == rule.Header {
+				index = i
+			}
+		}
+		if index < 0 {
+			result := HeaderResult{Name: rule.Header, Status: StatusMissing}
+			if hasHeader(resp.Header, rule.Header) {
+				result.Present, result.Status = true, StatusPresent
+				result.setValues(resp.Header.Values(rule.Header))
+			}
+			results = append(results, result)
+			index = len(results) - 1
+		}
+		result := &results[index]
+		result.Issues = append(result.Issues, f.Issues...)
+		result.Warnings = append(result.Warnings, f.Warnings...)
+		if len(f.Issues) > 0 && result.Status == StatusPresent {
+			result.Status = StatusMisconfigured
+		}
+	}
+	return results
+}
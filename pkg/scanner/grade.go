@@ -0,0 +1,136 @@
+package scanner
+
+import (
+	"net/http"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// gradeThreshold is the minimum score needed for a grade
+type gradeThreshold struct {
+	Grade string `yaml:"grade"`
+	Min   int    `yaml:"min"`
+}
+
+// gradeRubric defines how each URL is scored out of 100 and graded.
+//
+// Every required header is worth its weight in points when present and
+// correctly configured, a fraction of it when only served in report-only
+// mode, and nothing when misconfigured or missing. The earned points are
+// scaled to 100, then each warning and each additional finding (deprecated
+// or disclosure headers, misconfigured cookies, CORS or caching) deducts a
+// fixed penalty. The grade is the first threshold the score reaches.
+type gradeRubric struct {
+	Weights          map[string]int   `yaml:"weights"`
+	DefaultWeight    int              `yaml:"default_weight"`
+	ReportOnlyCredit float64          `yaml:"report_only_credit"`
+	WarningPenalty   int              `yaml:"warning_penalty"`
+	FindingPenalty   int              `yaml:"finding_penalty"`
+	Grades           []gradeThreshold `yaml:"grades"`
+}
+
+// Scorers maps each scoring algorithm to the function that scores a
+// response and its header results
+var Scorers = map[string]func(*http.Response, []HeaderResult) (int, string){
+	"rubric":          func(_ *http.Response, results []HeaderResult) (int, string) { return gradeHeaders(results) },
+	"observatory":     func(resp *http.Response, _ []HeaderResult) (int, string) { return observatoryScore(resp) },
+	"securityheaders": securityHeadersScore,
+}
+
+// ScorerNames returns the scoring algorithms in sorted order
+func ScorerNames() []string {
+	var names []string
+	for name := range Scorers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// rubric is the grading rubric in use, which --grading can override
+var rubric = gradeRubric{
+	Weights: map[string]int{
+		"Content-Security-Policy":      25,
+		"Strict-Transport-Security":    20,
+		"X-Frame-Options":              10,
+		"X-Content-Type-Options":       10,
+		"Referrer-Policy":              10,
+		"Permissions-Policy":           10,
+		"Cross-Origin-Opener-Policy":   5,
+		"Cross-Origin-Embedder-Policy": 5,
+		"Cross-Origin-Resource-Policy": 5,
+	},
+	DefaultWeight:    5,
+	ReportOnlyCredit: 0.25,
+	WarningPenalty:   1,
+	FindingPenalty:   5,
+	Grades: []gradeThreshold{
+		{"A+", 95},
+		{"A", 85},
+		{"B", 70},
+		{"C", 55},
+		{"D", 40},
+		{"F", 0},
+	},
+}
+
+// LoadRubric overrides the default rubric with the settings in a YAML or
+// JSON file. Weights are merged with the defaults, while grades replace them.
+func LoadRubric(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, &rubric); err != nil {
+		return err
+	}
+	sort.SliceStable(rubric.Grades, func(i, j int) bool {
+		return rubric.Grades[i].Min > rubric.Grades[j].Min
+	})
+	return nil
+}
+
+// gradeHeaders scores the header results and returns the score and grade
+func gradeHeaders(results []HeaderResult) (int, string) {
+	total, earned := 0.0, 0.0
+	penalty := 0
+	for _, result := range results {
+		penalty += len(result.Warnings) * rubric.WarningPenalty
+		if !IsRequiredHeader(result.Name) {
+			if result.Status != StatusPresent {
+				penalty += rubric.FindingPenalty
+			}
+			continue
+		}
+
+		weight, ok := rubric.Weights[result.Name]
+		if !ok {
+			weight = rubric.DefaultWeight
+		}
+		total += float64(weight)
+		switch result.Status {
+		case StatusPresent:
+			earned += float64(weight)
+		case StatusReportOnly:
+			earned += float64(weight) * rubric.ReportOnlyCredit
+		}
+	}
+
+	score := 100
+	if total > 0 {
+		score = int(earned/total*100 + 0.5)
+	}
+	score -= penalty
+	if score < 0 {
+		score = 0
+	}
+
+	for _, threshold := range rubric.Grades {
+		if score >= threshold.Min {
+			return score, threshold.Grade
+		}
+	}
+	return score, "F"
+}
@@ -0,0 +1,152 @@
+package scanner
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hstsPreloadMinAge is the minimum max-age accepted by the HSTS preload list
+const hstsPreloadMinAge = 31536000
+
+// HSTSMinAge is the max-age below which HSTS is reported as weak
+var HSTSMinAge int64 = hstsPreloadMinAge
+
+// hstsPolicy is a parsed Strict-Transport-Security header
+type hstsPolicy struct {
+	MaxAge            int64
+	HasMaxAge         bool
+	IncludeSubDomains bool
+	Preload           bool
+}
+
+// parseHSTS parses a Strict-Transport-Security header, recording each
+// problem that makes browsers ignore it
+func parseHSTS(value string, f *findings) hstsPolicy {
+	var policy hstsPolicy
+	seen := make(map[string]bool)
+	for _, directive := range strings.Split(value, ";") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if seen[name] {
+			f.issue("duplicate %s directive makes the header invalid", name)
+			continue
+		}
+		seen[name] = true
+
+		switch name {
+		case "max-age":
+			maxAge, err := strconv.ParseInt(strings.Trim(strings.TrimSpace(arg), `"`), 10, 64)
+			if err != nil || maxAge < 0 {
+				f.issue("invalid max-age %q", arg)
+				continue
+			}
+			policy.MaxAge, policy.HasMaxAge = maxAge, true
+		case "includesubdomains":
+			policy.IncludeSubDomains = true
+		case "preload":
+			policy.Preload = true
+		}
+	}
+	return policy
+}
+
+// validateHSTS checks the max-age, includeSubDomains and preload settings
+// of Strict-Transport-Security
+func validateHSTS(value string, f *findings) {
+	policy := parseHSTS(value, f)
+	switch {
+	case !policy.HasMaxAge:
+		f.issue("max-age directive is missing")
+		return
+	case policy.MaxAge == 0:
+		f.issue("max-age=0 disables HSTS")
+		return
+	case policy.MaxAge < HSTSMinAge:
+		f.warn("max-age=%d is below the recommended minimum of %d", policy.MaxAge, HSTSMinAge)
+	}
+
+	if !policy.IncludeSubDomains {
+		f.warn("includeSubDomains is not set, so subdomains can still be reached over HTTP")
+	}
+	if policy.Preload {
+		if policy.MaxAge < hstsPreloadMinAge {
+			f.warn("preload requires max-age of at least %d", hstsPreloadMinAge)
+		}
+		if !policy.IncludeSubDomains {
+			f.warn("preload requires includeSubDomains")
+		}
+	}
+}
+
+// acmeIssuers are the organizations of certificate authorities that only
+// issue through ACME, whose certificates are renewed automatically
+var acmeIssuers = []string{"Let's Encrypt", "ZeroSSL", "Google Trust Services", "Buypass"}
+
+// shortLivedCertificate is the lifetime up to which certificates are
+// considered short-lived, the 90 days of ACME CAs along with some slack
+const shortLivedCertificate = 100 * 24 * time.Hour
+
+// automatedRenewal reports whether there are signs that a host's
+// certificate is renewed automatically: an ACME-only issuer, or with --caa
+// CAA records binding issuance to an ACME account or validation method
+func automatedRenewal(ctx context.Context, host string, leaf *x509.Certificate) bool {
+	for _, organization := range leaf.Issuer.Organization {
+		for _, issuer := range acmeIssuers {
+			if strings.Contains(organization, issuer) {
+				return true
+			}
+		}
+	}
+	if CAAChecks && net.ParseIP(host) == nil {
+		for _, record := range lookupCAA(ctx, host).Records {
+			if strings.Contains(record.Value, "accounturi=") || strings.Contains(record.Value, "validationmethods=") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkHSTSLifetime warns when Strict-Transport-Security outlives the
+// certificate by far, or is preloaded while certificates rotate often,
+// and nothing suggests renewals are automated. A certificate that lapses
+// then locks browsers out for as long as they remember the policy, with no
+// way to click through the error.
+func checkHSTSLifetime(ctx context.Context, resp *http.Response, result *HeaderResult) {
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 || resp.Request == nil {
+		return
+	}
+	// Browsers only use the first of repeated headers, as validateHSTS
+	// grades
+	var f findings
+	value := result.Value
+	if len(result.Values) > 0 {
+		value = result.Values[0]
+	}
+	policy := parseHSTS(strings.TrimSpace(value), &f)
+	leaf := resp.TLS.PeerCertificates[0]
+	remaining := time.Until(leaf.NotAfter)
+	if !policy.HasMaxAge || policy.MaxAge == 0 || remaining <= 0 || automatedRenewal(ctx, resp.Request.URL.Hostname(), leaf) {
+		return
+	}
+
+	// max-age is compared in seconds, since huge values overflow a
+	// time.Duration
+	remainingSeconds := int64(remaining / time.Second)
+	lifetime := leaf.NotAfter.Sub(leaf.NotBefore)
+	switch {
+	case policy.Preload && lifetime <= shortLivedCertificate:
+		result.Warnings = append(result.Warnings, fmt.Sprintf("preload is set while certificates last only %d days with no sign of automated renewal, so a missed rotation locks out browsers", int(lifetime.Hours()/24)))
+	case policy.MaxAge > 2*remainingSeconds:
+		result.Warnings = append(result.Warnings, fmt.Sprintf("max-age of %d days far exceeds the %d days left on the certificate with no sign of automated renewal, so a lapsed renewal locks out browsers", policy.MaxAge/86400, int(remaining.Hours()/24)))
+	}
+}
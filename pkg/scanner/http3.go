@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+var (
+	// HTTP3Checks enables probing HTTPS URLs over HTTP/3
+	HTTP3Checks bool
+
+	// HTTP3Client sends the HTTP/3 probes over QUIC
+	HTTP3Client *http.Client
+)
+
+// NewHTTP3Client returns the client of the HTTP/3 probes, sharing the rate
+// limits of the other requests when limiter is not nil
+func NewHTTP3Client(tlsConfig *tls.Config, handshakeTimeout, timeout time.Duration, limiter *RateLimiter) *http.Client {
+	var transport http.RoundTripper = &http3.Transport{
+		TLSClientConfig: tlsConfig.Clone(),
+		QUICConfig:      &quic.Config{HandshakeIdleTimeout: handshakeTimeout},
+	}
+	if limiter != nil {
+		transport = &RateLimitedTransport{Next: transport, Limiter: limiter}
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
+
+// advertisesHTTP3 reports whether an Alt-Svc header offers HTTP/3
+func advertisesHTTP3(headers http.Header) bool {
+	for _, value := range headers.Values("Alt-Svc") {
+		for _, service := range strings.Split(value, ",") {
+			protocol, _, _ := strings.Cut(strings.TrimSpace(service), "=")
+			if protocol == "h3" || strings.HasPrefix(protocol, "h3-") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// noncePattern matches CSP nonces, which change with every response
+var noncePattern = regexp.MustCompile(`'nonce-[^']*'`)
+
+// checkHTTP3 fetches an HTTPS URL again over HTTP/3 and reports the required
+// headers that differ from the HTTP/1.1 or HTTP/2 response, since some CDNs
+// apply different header policies per protocol. It reports false when the
+// URL isn't served over HTTP/3 and its Alt-Svc header doesn't claim it is.
+func checkHTTP3(ctx context.Context, u *neturl.URL, auth Credentials, headers http.Header) (HeaderResult, bool) {
+	if u.Scheme != "https" {
+		return HeaderResult{}, false
+	}
+	result := HeaderResult{Name: "HTTP/3", Status: StatusMisconfigured}
+	resp, _, err := fetchWith(ctx, HTTP3Client, RequestMethod, u.String(), auth)
+	if err != nil {
+		if !advertisesHTTP3(headers) {
+			return HeaderResult{}, false
+		}
+		result.Issues = append(result.Issues, fmt.Sprintf("Alt-Svc advertises HTTP/3 but the probe failed: %v", err))
+		return result, true
+	}
+
+	var f findings
+	for _, header := range RequiredHeaders() {
+		value := noncePattern.ReplaceAllString(strings.Join(headers.Values(header), ", "), "'nonce'")
+		h3Value := noncePattern.ReplaceAllString(strings.Join(resp.Header.Values(header), ", "), "'nonce'")
+		switch {
+		case hasHeader(headers, header) && !hasHeader(resp.Header, header):
+			f.issue("%s is not sent over HTTP/3", header)
+		case !hasHeader(headers, header) && hasHeader(resp.Header, header):
+			f.warn("%s is only sent over HTTP/3", header)
+		case value != h3Value:
+			f.warn("%s differs over HTTP/3: %s", header, h3Value)
+		}
+	}
+	result.Present = true
+	result.Value = resp.Proto
+	result.Issues, result.Warnings = f.Issues, f.Warnings
+	if len(f.Issues) == 0 {
+		result.Status = StatusPresent
+	}
+	return result, true
+}
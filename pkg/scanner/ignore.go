@@ -0,0 +1,107 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// waiver accepts the risk of a header finding on the URLs matching a glob
+type waiver struct {
+	URL    string `yaml:"url"`
+	Header string `yaml:"header"`
+	// Expires is the last day, as YYYY-MM-DD, the waiver applies
+	Expires string `yaml:"expires"`
+	Reason  string `yaml:"reason"`
+
+	match *regexp.Regexp
+}
+
+// WaivedFinding is a header result suppressed by an ignore file entry,
+// along with the justification for accepting it
+type WaivedFinding struct {
+	HeaderResult `yaml:",inline"`
+	Reason       string `json:"reason" yaml:"reason"`
+	Expires      string `json:"expires,omitempty" yaml:"expires,omitempty"`
+}
+
+// waivers are the unexpired entries of the --ignore file
+var waivers []waiver
+
+// LoadIgnoreFile loads the accepted risks of a YAML or JSON ignore file.
+// Expired entries are logged and no longer suppress their finding.
+func LoadIgnoreFile(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	var entries []waiver
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	today := time.Now().Format("2006-01-02")
+	for _, entry := range entries {
+		if entry.URL == "" || entry.Header == "" {
+			return errors.New("every entry needs a url and a header")
+		}
+		if strings.TrimSpace(entry.Reason) == "" {
+			return fmt.Errorf("%s %s: a reason is required", entry.URL, entry.Header)
+		}
+		if entry.Expires != "" {
+			if _, err := time.Parse("2006-01-02", entry.Expires); err != nil {
+				return fmt.Errorf("%s %s: expires must be YYYY-MM-DD", entry.URL, entry.Header)
+			}
+			if entry.Expires < today {
+				log.Printf("Waiver for %s on %s expired on %s\n", entry.Header, entry.URL, entry.Expires)
+				continue
+			}
+		}
+		if entry.match, err = globPattern(entry.URL); err != nil {
+			return err
+		}
+		names := ParseHeaderList(entry.Header)
+		if len(names) == 0 {
+			return fmt.Errorf("%s %q: the header needs a name", entry.URL, entry.Header)
+		}
+		entry.Header = names[0]
+		waivers = append(waivers, entry)
+	}
+	return nil
+}
+
+// Waive moves the header results with findings that a waiver accepts from
+// the result's headers to its waived findings
+func Waive(result Result) Result {
+	if len(waivers) == 0 {
+		return result
+	}
+	var headers []HeaderResult
+	for _, header := range result.Headers {
+		hasFindings := header.Status != StatusPresent || len(header.Issues) > 0 || len(header.Warnings) > 0
+		if w, ok := findWaiver(result.URL, header.Name); ok && hasFindings {
+			result.Waived = append(result.Waived, WaivedFinding{HeaderResult: header, Reason: w.Reason, Expires: w.Expires})
+			continue
+		}
+		headers = append(headers, header)
+	}
+	result.Headers = headers
+	return result
+}
+
+// findWaiver returns the waiver for a header of the URL, matching the URL
+// as listed or with its default scheme
+func findWaiver(url, header string) (waiver, bool) {
+	for _, w := range waivers {
+		if w.Header == header && (w.match.MatchString(url) || w.match.MatchString(normalizeURL(url))) {
+			return w, true
+		}
+	}
+	return waiver{}, false
+}
@@ -0,0 +1,69 @@
+package scanner
+
+import (
+	"net/http"
+	"strings"
+)
+
+// passiveTags are the elements that load passive content, which browsers
+// upgrade to HTTPS or show with a warning instead of blocking
+var passiveTags = map[string]bool{
+	"img":    true,
+	"audio":  true,
+	"video":  true,
+	"source": true,
+	"track":  true,
+}
+
+// checkMixedContent reports subresources an HTTPS page loads over plain
+// HTTP. Browsers block active mixed content such as scripts, and passive
+// content such as images can be tampered with in transit. It reports false
+// when the page loads no mixed content.
+func checkMixedContent(headers http.Header, resources []subresource) (HeaderResult, bool) {
+	// upgrade-insecure-requests makes browsers fetch every resource over
+	// HTTPS, so the references only break if the host does not serve it
+	upgrade, block := false, false
+	for _, policy := range parseCSPList(strings.Join(headers.Values("Content-Security-Policy"), ",")) {
+		_, ok := policy.directive("upgrade-insecure-requests")
+		upgrade = upgrade || ok
+		_, ok = policy.directive("block-all-mixed-content")
+		block = block || ok
+	}
+
+	var f findings
+	for _, resource := range resources {
+		if resource.URL.Scheme != "http" {
+			continue
+		}
+		switch {
+		case upgrade:
+			f.warn("<%s> references %s over HTTP, which upgrade-insecure-requests loads over HTTPS", resource.Tag, resource.URL)
+		case passiveTags[resource.Tag]:
+			f.warn("<%s> loads passive content %s over HTTP", resource.Tag, resource.URL)
+		default:
+			f.issue("<%s> loads active content %s over HTTP, which browsers block", resource.Tag, resource.URL)
+		}
+	}
+	if len(f.Issues) == 0 && len(f.Warnings) == 0 {
+		return HeaderResult{}, false
+	}
+	if !upgrade {
+		if block {
+			f.warn("the CSP sets the deprecated block-all-mixed-content, which current browsers ignore, add upgrade-insecure-requests to load these resources over HTTPS")
+		} else {
+			f.warn("the CSP does not set upgrade-insecure-requests, which would load these resources over HTTPS")
+		}
+	}
+
+	result := HeaderResult{
+		Name:     "Mixed-Content",
+		Present:  true,
+		Status:   StatusPresent,
+		Issues:   f.Issues,
+		Warnings: f.Warnings,
+	}
+	if len(f.Issues) > 0 {
+		result.Status = StatusMisconfigured
+	}
+	return result, true
+}
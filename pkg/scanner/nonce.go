@@ -0,0 +1,61 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+// NonceChecks enables fetching pages a second time to verify that their CSP
+// nonces change between responses
+var NonceChecks bool
+
+// cspNonces returns the nonce sources of every policy of a
+// Content-Security-Policy
+func cspNonces(value string) []string {
+	var nonces []string
+	for _, policy := range parseCSPList(value) {
+		for _, directive := range policy.Directives {
+			for _, source := range directive.Sources {
+				if strings.HasPrefix(strings.ToLower(source), "'nonce-") {
+					nonces = append(nonces, source)
+				}
+			}
+		}
+	}
+	return nonces
+}
+
+// checkNonces fetches the page again and reports the nonces of the
+// Content-Security-Policy result that did not change. A static nonce can be
+// read from any response and reused by injected scripts.
+func checkNonces(ctx context.Context, c *http.Client, u *neturl.URL, auth Credentials, result *HeaderResult) {
+	nonces := cspNonces(result.Value)
+	if len(nonces) == 0 {
+		return
+	}
+
+	resp, _, err := fetch(ctx, c, u.String(), auth)
+	if err != nil {
+		result.Warnings = append(result.Warnings, "could not fetch the page again to compare nonces: "+err.Error())
+		return
+	}
+	again := make(map[string]bool)
+	for _, nonce := range cspNonces(strings.Join(resp.Header.Values("Content-Security-Policy"), ", ")) {
+		again[nonce] = true
+	}
+
+	var f findings
+	reported := make(map[string]bool)
+	for _, nonce := range nonces {
+		if again[nonce] && !reported[nonce] {
+			reported[nonce] = true
+			cspFinding(&f, cspHigh, "nonce %s did not change between requests, so injected scripts can reuse it", nonce)
+		}
+	}
+	if len(f.Issues) > 0 {
+		result.Issues = append(result.Issues, f.Issues...)
+		result.Status = StatusMisconfigured
+	}
+}
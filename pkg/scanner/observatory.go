@@ -0,0 +1,255 @@
+package scanner
+
+import (
+	"net/http"
+	"strings"
+)
+
+// observatoryGrades maps Mozilla HTTP Observatory scores to grades
+var observatoryGrades = []gradeThreshold{
+	{"A+", 100},
+	{"A", 90},
+	{"A-", 85},
+	{"B+", 80},
+	{"B", 70},
+	{"B-", 65},
+	{"C+", 60},
+	{"C", 50},
+	{"C-", 45},
+	{"D+", 40},
+	{"D", 30},
+	{"D-", 25},
+	{"F", 0},
+}
+
+// observatoryHSTSMinAge is the max-age below which the Observatory
+// penalizes Strict-Transport-Security, six months in seconds
+const observatoryHSTSMinAge = 15768000
+
+// observatoryScore scores a response the way Mozilla HTTP Observatory does.
+// Every test adds a modifier to a baseline of 100, and bonuses only count
+// once the score is at least 90 without them. The redirection and
+// subresource integrity tests need more than the response headers and are
+// not scored, nor is the HSTS preload bonus, which needs the preload list.
+func observatoryScore(resp *http.Response) (int, string) {
+	headers := resp.Header
+	https := resp.Request != nil && resp.Request.URL.Scheme == "https"
+	hsts := observatoryHSTS(headers, https)
+
+	modifiers := []int{
+		observatoryCSP(headers),
+		observatoryCookies(headers, hsts > -20),
+		observatoryCORS(headers),
+		observatoryReferrerPolicy(headers),
+		hsts,
+		observatoryXContentTypeOptions(headers),
+		observatoryXFrameOptions(headers),
+		observatoryCORP(headers),
+	}
+
+	score, bonus := 100, 0
+	for _, modifier := range modifiers {
+		if modifier > 0 {
+			bonus += modifier
+		} else {
+			score += modifier
+		}
+	}
+	if score >= 90 {
+		score += bonus
+	}
+	if score < 0 {
+		score = 0
+	}
+
+	for _, threshold := range observatoryGrades {
+		if score >= threshold.Min {
+			return score, threshold.Grade
+		}
+	}
+	return score, "F"
+}
+
+// observatoryCSP scores the Content-Security-Policy. Report-only policies
+// are not enforced and score the same as no policy.
+func observatoryCSP(headers http.Header) int {
+	if !hasHeader(headers, "Content-Security-Policy") {
+		return -25
+	}
+	policy := parseCSP(headers.Get("Content-Security-Policy"))
+	if len(policy.Directives) == 0 {
+		return -25
+	}
+
+	// Scripts and plugins that are not locked down count as unsafe-inline
+	script, ok := policy.effective("script-src")
+	unsafe := !ok || script.has("data:") || len(script.wildcardSources()) > 0 ||
+		script.has("'unsafe-inline'") && !script.usesNonceOrHash() && !script.has("'strict-dynamic'")
+	if object, ok := policy.effective("object-src"); !ok || object.has("data:") || len(object.wildcardSources()) > 0 {
+		unsafe = true
+	}
+
+	insecureActive, insecurePassive := false, false
+	for _, directive := range policy.Directives {
+		for _, source := range directive.Sources {
+			if !strings.HasPrefix(strings.ToLower(source), "http:") {
+				continue
+			}
+			if directive.Name == "img-src" || directive.Name == "media-src" {
+				insecurePassive = true
+			} else {
+				insecureActive = true
+			}
+		}
+	}
+
+	style, _ := policy.effective("style-src")
+	defaultSrc, _ := policy.directive("default-src")
+	switch {
+	case unsafe, insecureActive:
+		return -20
+	case script.has("'unsafe-eval'"), insecurePassive:
+		return -10
+	case style.has("'unsafe-inline'"):
+		return 0
+	case len(defaultSrc.Sources) == 1 && defaultSrc.has("'none'"):
+		return 10
+	default:
+		return 5
+	}
+}
+
+// observatoryCookies scores the Set-Cookie headers by their worst cookie.
+// Sessions are recognized by name, as the Observatory does.
+func observatoryCookies(headers http.Header, hsts bool) int {
+	values := headers.Values("Set-Cookie")
+	if len(values) == 0 {
+		return 0
+	}
+
+	worst, sameSite := 0, true
+	for _, value := range values {
+		cookie, err := http.ParseSetCookie(value)
+		if err != nil {
+			continue
+		}
+		name := strings.ToLower(cookie.Name)
+		session := strings.Contains(name, "login") || strings.Contains(name, "sess")
+
+		modifier := 0
+		switch {
+		case session && !cookie.Secure && !hsts:
+			modifier = -40
+		case session && !cookie.HttpOnly:
+			modifier = -30
+		case !cookie.Secure && !hsts:
+			modifier = -20
+		case cookie.SameSite == http.SameSiteDefaultMode:
+			modifier = -20
+		case strings.Contains(name, "csrf") && cookie.SameSite == 0:
+			modifier = -20
+		case session && !cookie.Secure:
+			modifier = -10
+		case !cookie.Secure:
+			modifier = -5
+		}
+		if modifier < worst {
+			worst = modifier
+		}
+		if cookie.SameSite == 0 {
+			sameSite = false
+		}
+	}
+	if worst == 0 && sameSite {
+		return 5
+	}
+	return worst
+}
+
+// observatoryCORS penalizes responses that let any origin read them with
+// the user's credentials
+func observatoryCORS(headers http.Header) int {
+	origin := strings.TrimSpace(headers.Get("Access-Control-Allow-Origin"))
+	credentials := strings.TrimSpace(headers.Get("Access-Control-Allow-Credentials")) == "true"
+	if credentials && (strings.EqualFold(origin, "null") || CORSOrigin != "" && origin == CORSOrigin) {
+		return -50
+	}
+	return 0
+}
+
+// observatoryReferrerPolicy scores the Referrer-Policy, rewarding policies
+// that keep the URL private
+func observatoryReferrerPolicy(headers http.Header) int {
+	if !hasHeader(headers, "Referrer-Policy") {
+		return 0
+	}
+	effective := ""
+	for _, token := range SplitList(strings.Join(headers.Values("Referrer-Policy"), ",")) {
+		if token = strings.ToLower(token); referrerPolicyTokens[token] {
+			effective = token
+		}
+	}
+	switch effective {
+	case "no-referrer", "same-origin", "strict-origin", "strict-origin-when-cross-origin":
+		return 5
+	case "no-referrer-when-downgrade":
+		return 0
+	default:
+		return -5
+	}
+}
+
+// observatoryHSTS scores Strict-Transport-Security, which browsers ignore
+// over plain HTTP
+func observatoryHSTS(headers http.Header, https bool) int {
+	if !https || !hasHeader(headers, "Strict-Transport-Security") {
+		return -20
+	}
+	var f findings
+	policy := parseHSTS(headers.Get("Strict-Transport-Security"), &f)
+	switch {
+	case len(f.Issues) > 0, !policy.HasMaxAge:
+		return -20
+	case policy.MaxAge < observatoryHSTSMinAge:
+		return -10
+	default:
+		return 0
+	}
+}
+
+// observatoryXContentTypeOptions scores X-Content-Type-Options
+func observatoryXContentTypeOptions(headers http.Header) int {
+	if strings.EqualFold(strings.TrimSpace(headers.Get("X-Content-Type-Options")), "nosniff") {
+		return 0
+	}
+	return -5
+}
+
+// observatoryXFrameOptions scores X-Frame-Options, rewarding sites that use
+// the CSP frame-ancestors directive instead
+func observatoryXFrameOptions(headers http.Header) int {
+	if hasHeader(headers, "Content-Security-Policy") {
+		if _, ok := parseCSP(headers.Get("Content-Security-Policy")).directive("frame-ancestors"); ok {
+			return 5
+		}
+	}
+	value := strings.ToUpper(strings.TrimSpace(headers.Get("X-Frame-Options")))
+	if value == "DENY" || value == "SAMEORIGIN" || strings.HasPrefix(value, "ALLOW-FROM") {
+		return 0
+	}
+	return -20
+}
+
+// observatoryCORP scores Cross-Origin-Resource-Policy, which is optional
+// but penalized when invalid
+func observatoryCORP(headers http.Header) int {
+	if !hasHeader(headers, "Cross-Origin-Resource-Policy") {
+		return 0
+	}
+	switch crossOriginPolicy(headers.Get("Cross-Origin-Resource-Policy")) {
+	case "same-origin", "same-site", "cross-origin":
+		return 0
+	default:
+		return -5
+	}
+}
@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// OCSPChecks enables reporting whether servers staple OCSP responses
+var OCSPChecks bool
+
+// checkOCSP reports whether the server stapled an OCSP response to the TLS
+// handshake, which spares browsers a revocation lookup that they otherwise
+// skip when it fails, and whether the stapled response is current and good.
+// It reports false when the response wasn't served over TLS or the
+// certificate names no OCSP responder, so there is nothing to staple.
+func checkOCSP(resp *http.Response) (HeaderResult, bool) {
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		return HeaderResult{}, false
+	}
+	leaf := resp.TLS.PeerCertificates[0]
+	if len(resp.TLS.OCSPResponse) == 0 {
+		if len(leaf.OCSPServer) == 0 {
+			return HeaderResult{}, false
+		}
+		return HeaderResult{Name: "OCSP-Stapling", Category: categoryTransport, Status: StatusMissing}, true
+	}
+
+	// The signature can only be checked against the issuer when the server
+	// sent it along with the leaf
+	var issuer *x509.Certificate
+	if len(resp.TLS.PeerCertificates) > 1 {
+		issuer = resp.TLS.PeerCertificates[1]
+	}
+	result := HeaderResult{Name: "OCSP-Stapling", Category: categoryTransport, Present: true, Status: StatusMisconfigured}
+	staple, err := ocsp.ParseResponse(resp.TLS.OCSPResponse, issuer)
+	if err != nil {
+		result.Issues = append(result.Issues, fmt.Sprintf("the stapled OCSP response is invalid: %v", err))
+		return result, true
+	}
+
+	var f findings
+	switch staple.Status {
+	case ocsp.Good:
+		result.Value = "good"
+	case ocsp.Revoked:
+		result.Value = "revoked"
+		f.issue("the stapled OCSP response says the certificate was revoked on %s", staple.RevokedAt.Format("2006-01-02"))
+	default:
+		result.Value = "unknown"
+		f.warn("the OCSP responder does not know the certificate")
+	}
+	if !staple.NextUpdate.IsZero() && staple.NextUpdate.Before(time.Now()) {
+		f.issue("the stapled OCSP response expired on %s", staple.NextUpdate.Format("2006-01-02"))
+	}
+	if staple.SerialNumber.Cmp(leaf.SerialNumber) != 0 {
+		f.issue("the stapled OCSP response is for another certificate")
+	}
+	result.Issues, result.Warnings = f.Issues, f.Warnings
+	if len(f.Issues) == 0 {
+		result.Status = StatusPresent
+	}
+	return result, true
+}
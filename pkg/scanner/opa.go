@@ -0,0 +1,98 @@
+//go:build !js
+
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+)
+
+// opaPackage is the package of the Rego policies loaded with --opa-policy
+const opaPackage = "data.gosecurityheaders"
+
+// opaQuery is the prepared query of the loaded Rego policy, or nil
+var opaQuery *rego.PreparedEvalQuery
+
+// LoadOPAPolicy loads a Rego policy evaluated against each result. The
+// policy is in the gosecurityheaders package, and its deny and warn rules
+// hold the messages of the violations, e.g.
+//
+//	deny contains msg if {
+//		some header in input.headers
+//		header.name == "Content-Security-Policy"
+//		header.status != "Present"
+//		msg := "every service must enforce a Content-Security-Policy"
+//	}
+//
+// The input is the result in the format of the JSON output.
+func LoadOPAPolicy(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	module, err := ast.ParseModule(filePath, string(data))
+	if err != nil {
+		return err
+	}
+	if module.Package.Path.String() != opaPackage {
+		return fmt.Errorf("the policy is in package %s instead of gosecurityheaders", strings.TrimPrefix(module.Package.Path.String(), "data."))
+	}
+	query, err := rego.New(rego.Query(opaPackage), rego.ParsedModule(module)).PrepareForEval(context.Background())
+	if err != nil {
+		return err
+	}
+	opaQuery = &query
+	return nil
+}
+
+// checkOPA evaluates the loaded Rego policy against a result, reporting the
+// deny messages as issues and the warn messages as warnings of an
+// OPA-Policy result. It reports false without a policy.
+func checkOPA(ctx context.Context, r Result) (HeaderResult, bool) {
+	if opaQuery == nil {
+		return HeaderResult{}, false
+	}
+	result := HeaderResult{Name: "OPA-Policy", Present: true, Status: StatusPresent}
+	var f findings
+	rs, err := opaQuery.Eval(ctx, rego.EvalInput(r))
+	switch {
+	case err != nil:
+		f.warn("the OPA policy could not be evaluated: %v", err)
+	case len(rs) > 0 && len(rs[0].Expressions) > 0:
+		rules, _ := rs[0].Expressions[0].Value.(map[string]interface{})
+		for _, message := range opaMessages(rules["deny"]) {
+			f.issue("%s", message)
+		}
+		for _, message := range opaMessages(rules["warn"]) {
+			f.warn("%s", message)
+		}
+	}
+	result.Issues, result.Warnings = f.Issues, f.Warnings
+	if len(result.Issues) > 0 {
+		result.Status = StatusMisconfigured
+	}
+	result.Severity = SeverityFor(result)
+	return result, true
+}
+
+// opaMessages returns the messages of a deny or warn rule, a set of
+// strings, in sorted order
+func opaMessages(value interface{}) []string {
+	values, _ := value.([]interface{})
+	messages := make([]string, 0, len(values))
+	for _, value := range values {
+		if message, ok := value.(string); ok {
+			messages = append(messages, message)
+		} else {
+			messages = append(messages, fmt.Sprint(value))
+		}
+	}
+	sort.Strings(messages)
+	return messages
+}
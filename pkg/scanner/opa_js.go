@@ -0,0 +1,19 @@
+//go:build js
+
+package scanner
+
+import (
+	"context"
+	"errors"
+)
+
+// LoadOPAPolicy fails in WebAssembly builds, which leave OPA out to keep
+// the module small
+func LoadOPAPolicy(filePath string) error {
+	return errors.New("OPA policies are not supported by WebAssembly builds")
+}
+
+// checkOPA reports false, as WebAssembly builds can't load a policy
+func checkOPA(ctx context.Context, r Result) (HeaderResult, bool) {
+	return HeaderResult{}, false
+}
@@ -0,0 +1,214 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"sync"
+	"time"
+)
+
+// Options configure a Scanner. The zero value scans one URL at a time with
+// the rubric scoring and the package Client, or a client with the
+// command's default timeouts when Client was not set.
+type Options struct {
+	// Client sends the requests, instead of the package Client
+	Client *http.Client
+	// Timeout bounds each request of the default client, including
+	// redirects and reading the body (default 30s)
+	Timeout time.Duration
+	// InsecureSkipVerify disables certificate verification of the default
+	// client, like --skip-ssl
+	InsecureSkipVerify bool
+	// Concurrency is the number of URLs scanned in parallel (default 1)
+	Concurrency int
+	// Scoring is the scoring algorithm, one of ScorerNames (default rubric)
+	Scoring string
+	// Auth authenticates the requests to every URL
+	Auth Credentials
+
+	// The hooks are called while Scan runs, as each URL finishes rather
+	// than in input order, so results can be streamed to other sinks. They
+	// are called one at a time from the goroutines of the scan.
+
+	// OnResult is called with the result of each URL that was fetched
+	OnResult func(Result)
+	// OnError is called with each URL that failed. URLs abandoned because
+	// ctx is done are only counted by OnProgress.
+	OnError func(url string, err error)
+	// OnProgress is called with the number of URLs finished so far, failed
+	// and skipped ones included, and the number of URLs of the scan
+	OnProgress func(done, total int)
+}
+
+// Scanner scans URLs with the settings of its Options. The checks
+// themselves are the registered ones, configured by package variables,
+// which are process-global: every Scanner of the process shares them, so
+// they are set once before scanning. Only the registry of checks, changed
+// with Register, Enable and Disable, is safe to change while scanning.
+type Scanner struct {
+	opts   Options
+	client *http.Client
+}
+
+// New returns a Scanner with the given options
+func New(opts Options) *Scanner {
+	s := &Scanner{opts: opts, client: opts.Client}
+	if s.client == nil {
+		s.client = Client
+	}
+	if s.client == nil {
+		s.client = defaultClient(opts)
+	}
+	if s.opts.Concurrency < 1 {
+		s.opts.Concurrency = 1
+	}
+	if s.opts.Scoring == "" {
+		s.opts.Scoring = "rubric"
+	}
+	return s
+}
+
+// timeout returns the Timeout of the default client
+func (opts Options) timeout() time.Duration {
+	if opts.Timeout == 0 {
+		return 30 * time.Second
+	}
+	return opts.Timeout
+}
+
+// Scan scans the URLs and returns the results of those that could be
+// fetched, in input order, calling the hooks of the Options as each URL
+// finishes. Failed URLs are left out and reported in the error, along with
+// ctx's error when it is done before every URL was scanned. URLs skipped by
+// --non-2xx or robots.txt are left out silently.
+func (s *Scanner) Scan(ctx context.Context, urls []string) ([]Result, error) {
+	score, ok := Scorers[s.opts.Scoring]
+	if !ok {
+		return nil, fmt.Errorf("unsupported scoring algorithm: %s", s.opts.Scoring)
+	}
+	targets := make([]Target, len(urls))
+	for i, url := range urls {
+		targets[i] = Target{URL: url, Auth: s.opts.Auth}
+	}
+
+	outcomes := scanTargets(ctx, s.client, targets, s.opts.Concurrency, score)
+	scans := make([]ScanResult, len(outcomes))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	finished := 0
+	for i, outcome := range outcomes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scan := <-outcome
+			mu.Lock()
+			defer mu.Unlock()
+			scans[i] = scan
+			finished++
+			s.notify(ctx, urls[i], scan, finished, len(urls))
+		}()
+	}
+	wg.Wait()
+
+	var results []Result
+	var errs []error
+	for i, scan := range scans {
+		switch {
+		case abandoned(ctx, scan.Err):
+			// Reported once below rather than for every URL
+		case scan.Err != nil:
+			errs = append(errs, fmt.Errorf("%s: %w", urls[i], scan.Err))
+		case scan.Skip == "":
+			results = append(results, scan.Result)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return results, errors.Join(errs...)
+}
+
+// notify calls the hooks with a URL that finished
+func (s *Scanner) notify(ctx context.Context, url string, scan ScanResult, finished, total int) {
+	switch {
+	case abandoned(ctx, scan.Err):
+	case scan.Err != nil:
+		if s.opts.OnError != nil {
+			s.opts.OnError(url, scan.Err)
+		}
+	case scan.Skip == "":
+		if s.opts.OnResult != nil {
+			s.opts.OnResult(scan.Result)
+		}
+	}
+	if s.opts.OnProgress != nil {
+		s.opts.OnProgress(finished, total)
+	}
+}
+
+// abandoned reports whether err is of a URL abandoned because ctx is done
+func abandoned(ctx context.Context, err error) bool {
+	return err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err())
+}
+
+// Response is a response fetched outside the scanner, such as with the
+// fetch API of a browser extension
+type Response struct {
+	URL        string
+	StatusCode int
+	// Protocol is the protocol of the response, e.g. HTTP/2.0 (default
+	// HTTP/1.1)
+	Protocol string
+	Header   http.Header
+	// Body is the start of the body, for the checks of the content
+	Body []byte
+}
+
+// CheckResponse checks and grades a response fetched elsewhere. Without the
+// connection, the checks of the TLS certificate and the redirect chain are
+// left out, while those sending requests of their own, like --nonces, use
+// the client of the Scanner.
+func (s *Scanner) CheckResponse(ctx context.Context, r Response) (Result, error) {
+	score, ok := Scorers[s.opts.Scoring]
+	if !ok {
+		return Result{}, fmt.Errorf("unsupported scoring algorithm: %s", s.opts.Scoring)
+	}
+	u, err := neturl.Parse(normalizeURL(r.URL))
+	if err != nil {
+		return Result{}, err
+	}
+	resp := &http.Response{
+		StatusCode: r.StatusCode,
+		Proto:      r.Protocol,
+		Header:     r.Header,
+		Request:    &http.Request{Method: RequestMethod, URL: u, Header: http.Header{}},
+	}
+	if resp.Proto == "" {
+		resp.Proto = "HTTP/1.1"
+	}
+	resp.ProtoMajor, resp.ProtoMinor, _ = http.ParseHTTPVersion(resp.Proto)
+	if resp.Header == nil {
+		resp.Header = http.Header{}
+	}
+
+	t := Target{URL: r.URL, Auth: s.opts.Auth}
+	headers := check(ctx, s.client, t, resp, r.Body)
+	result := Result{
+		URL:             r.URL,
+		Headers:         headers,
+		StatusCode:      r.StatusCode,
+		Protocol:        resp.Proto,
+		ContentEncoding: resp.Header.Get("Content-Encoding"),
+	}
+	result.Score, result.Grade = score(resp, headers)
+	if !successful(r.StatusCode) && StatusHandling == "separate" {
+		result.Score, result.Grade = 0, UngradedGrade
+	}
+	if opa, ok := checkOPA(ctx, result); ok {
+		result.Headers = append(result.Headers, opa)
+	}
+	return result, nil
+}
@@ -0,0 +1,111 @@
+package scanner
+
+import (
+	"bytes"
+	neturl "net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// subresource is a resource referenced by an HTML page
+type subresource struct {
+	Tag string
+	// URL is resolved against the page URL and any <base> element
+	URL       *neturl.URL
+	Integrity string
+	// CrossOrigin reports whether the crossorigin attribute is set, which
+	// cross-origin resources need for their integrity to be checked
+	CrossOrigin bool
+	// SRI reports whether the element supports the integrity attribute
+	SRI bool
+	// ThirdParty reports whether the resource is loaded from another host
+	ThirdParty bool
+}
+
+// subresourceAttrs maps elements to the attribute holding the URL of the
+// resource they load
+var subresourceAttrs = map[string]string{
+	"script": "src",
+	"link":   "href",
+	"img":    "src",
+	"iframe": "src",
+	"frame":  "src",
+	"audio":  "src",
+	"video":  "src",
+	"source": "src",
+	"track":  "src",
+	"embed":  "src",
+	"object": "data",
+}
+
+// subresourceLinks are the link relations that load a resource, as opposed
+// to pointing at another page
+var subresourceLinks = map[string]bool{
+	"stylesheet":    true,
+	"icon":          true,
+	"preload":       true,
+	"modulepreload": true,
+	"prefetch":      true,
+	"manifest":      true,
+}
+
+// parseSubresources returns the resources loaded by the elements of an
+// HTML page
+func parseSubresources(body []byte, page *neturl.URL) []subresource {
+	base := page
+	var resources []subresource
+	tokenizer := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return resources
+		case html.StartTagToken, html.SelfClosingTagToken:
+		default:
+			continue
+		}
+
+		token := tokenizer.Token()
+		attrs := make(map[string]string)
+		for _, attr := range token.Attr {
+			attrs[attr.Key] = attr.Val
+		}
+		_, crossOrigin := attrs["crossorigin"]
+
+		if token.Data == "base" {
+			if href, err := page.Parse(strings.TrimSpace(attrs["href"])); err == nil && attrs["href"] != "" {
+				base = href
+			}
+			continue
+		}
+		attr, ok := subresourceAttrs[token.Data]
+		if !ok || attrs[attr] == "" {
+			continue
+		}
+		sri := token.Data == "script"
+		if token.Data == "link" {
+			rels := strings.Fields(strings.ToLower(attrs["rel"]))
+			loads := false
+			for _, rel := range rels {
+				loads = loads || subresourceLinks[rel]
+				sri = sri || rel == "stylesheet" || rel == "preload" || rel == "modulepreload"
+			}
+			if !loads {
+				continue
+			}
+		}
+
+		u, err := base.Parse(strings.TrimSpace(attrs[attr]))
+		if err != nil {
+			continue
+		}
+		resources = append(resources, subresource{
+			Tag:         token.Data,
+			URL:         u,
+			Integrity:   attrs["integrity"],
+			CrossOrigin: crossOrigin,
+			SRI:         sri,
+			ThirdParty:  (u.Scheme == "http" || u.Scheme == "https") && !strings.EqualFold(u.Host, page.Host),
+		})
+	}
+}
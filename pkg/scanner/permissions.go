@@ -0,0 +1,182 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// permissionsFeature is a single feature of a Permissions-Policy along with
+// the origins it is allowed for
+type permissionsFeature struct {
+	Name      string
+	Allowlist []string
+}
+
+// sensitiveFeatures are the Permissions-Policy features that expose
+// hardware or personal data and should never be granted to every origin
+var sensitiveFeatures = map[string]bool{
+	"bluetooth":       true,
+	"camera":          true,
+	"clipboard-read":  true,
+	"display-capture": true,
+	"geolocation":     true,
+	"hid":             true,
+	"microphone":      true,
+	"midi":            true,
+	"payment":         true,
+	"serial":          true,
+	"usb":             true,
+}
+
+// parsePermissionsPolicy parses a Permissions-Policy, which uses the
+// structured field dictionary syntax, e.g. camera=(), geolocation=(self "https://a.example")
+func parsePermissionsPolicy(value string) ([]permissionsFeature, error) {
+	p := &sfParser{s: value}
+	var features []permissionsFeature
+	for {
+		p.skipSpace()
+		if p.done() {
+			return features, nil
+		}
+		name, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		feature := permissionsFeature{Name: name}
+		if p.consume('=') {
+			if p.consume('(') {
+				for {
+					p.skipSpace()
+					if p.consume(')') {
+						break
+					}
+					item, err := p.item()
+					if err != nil {
+						return nil, err
+					}
+					feature.Allowlist = append(feature.Allowlist, item)
+				}
+			} else {
+				item, err := p.item()
+				if err != nil {
+					return nil, err
+				}
+				feature.Allowlist = []string{item}
+			}
+		}
+		p.skipParams()
+		features = append(features, feature)
+
+		p.skipSpace()
+		if p.done() {
+			return features, nil
+		}
+		if !p.consume(',') {
+			return nil, fmt.Errorf("expected ',' at position %d", p.pos)
+		}
+	}
+}
+
+// sfParser is a minimal parser for structured field values (RFC 8941),
+// covering the subset used by Permissions-Policy
+type sfParser struct {
+	s   string
+	pos int
+}
+
+func (p *sfParser) done() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *sfParser) skipSpace() {
+	for !p.done() && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *sfParser) consume(c byte) bool {
+	if !p.done() && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// key parses a dictionary key
+func (p *sfParser) key() (string, error) {
+	start := p.pos
+	for !p.done() {
+		c := p.s[p.pos]
+		if (c >= 'a' && c <= 'z') || c == '*' || (p.pos > start && ((c >= '0' && c <= '9') || c == '_' || c == '-' || c == '.')) {
+			p.pos++
+			continue
+		}
+		break
+	}
+	if p.pos == start {
+		return "", fmt.Errorf("invalid key at position %d", p.pos)
+	}
+	return p.s[start:p.pos], nil
+}
+
+// item parses a token or quoted string, returning strings with their
+// quotes so origins can be told apart from the self and * tokens
+func (p *sfParser) item() (string, error) {
+	start := p.pos
+	if p.consume('"') {
+		for !p.done() && p.s[p.pos] != '"' {
+			if p.s[p.pos] == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		if !p.consume('"') {
+			return "", fmt.Errorf("unterminated string at position %d", start)
+		}
+	} else {
+		for !p.done() && !strings.ContainsRune(" \t,()=;", rune(p.s[p.pos])) {
+			p.pos++
+		}
+		if p.pos == start {
+			return "", fmt.Errorf("invalid item at position %d", p.pos)
+		}
+	}
+	item := p.s[start:p.pos]
+	p.skipParams()
+	return item, nil
+}
+
+// skipParams skips any ;key=value parameters following an item
+func (p *sfParser) skipParams() {
+	for p.consume(';') {
+		p.skipSpace()
+		p.key()
+		if p.consume('=') {
+			p.item()
+		}
+	}
+}
+
+// validatePermissionsPolicy parses the Permissions-Policy and warns about
+// sensitive features that any origin may use
+func validatePermissionsPolicy(value string, f *findings) {
+	features, err := parsePermissionsPolicy(value)
+	if err != nil {
+		f.issue("invalid syntax makes browsers ignore the policy: %v", err)
+		return
+	}
+	if len(features) == 0 {
+		f.issue("policy is empty")
+		return
+	}
+	for _, feature := range features {
+		for _, origin := range feature.Allowlist {
+			if origin == "*" && sensitiveFeatures[feature.Name] {
+				f.warn("%s is allowed for every origin", feature.Name)
+			}
+		}
+		if strings.Contains(feature.Name, "*") {
+			f.warn("%q is not a valid feature name", feature.Name)
+		}
+	}
+}
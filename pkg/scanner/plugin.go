@@ -0,0 +1,129 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// commandTimeout bounds each run of a command check
+const commandTimeout = 30 * time.Second
+
+// commandCheck is a check run by an external program, see NewCommandCheck
+type commandCheck struct {
+	path        string
+	id          string
+	description string
+	severity    Severity
+}
+
+// commandResponse is the response a command check is given on standard
+// input. The body is base64 encoded, as JSON encodes byte slices.
+type commandResponse struct {
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Protocol   string      `json:"protocol"`
+	Headers    http.Header `json:"headers"`
+	Body       []byte      `json:"body,omitempty"`
+}
+
+// NewCommandCheck returns a check run by the program at path, which can be
+// written in any language. Run with the describe argument, the program
+// prints its check as {"id": ..., "description": ..., "severity": ...}.
+// Run without arguments it reads a response from standard input as
+// {"url", "status_code", "protocol", "headers", "body"} and prints a header
+// result in the format of the JSON output, or nothing when the check doesn't
+// apply to the response.
+func NewCommandCheck(path string) (Check, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	output, err := runCommand(ctx, path, nil, "describe")
+	if err != nil {
+		return nil, err
+	}
+	var description struct {
+		ID          string `json:"id"`
+		Description string `json:"description"`
+		Severity    string `json:"severity"`
+	}
+	if err := json.Unmarshal(output, &description); err != nil {
+		return nil, fmt.Errorf("%s describe: %v", filepath.Base(path), err)
+	}
+	if description.ID == "" {
+		return nil, fmt.Errorf("%s describe: no id", filepath.Base(path))
+	}
+	c := &commandCheck{path: path, id: description.ID, description: description.Description, severity: SeverityMedium}
+	if description.Severity != "" {
+		if c.severity, err = parseSeverity(description.Severity); err != nil {
+			return nil, fmt.Errorf("%s describe: %v", filepath.Base(path), err)
+		}
+	}
+	return c, nil
+}
+
+func (c *commandCheck) ID() string          { return c.id }
+func (c *commandCheck) Description() string { return c.description }
+func (c *commandCheck) Severity() Severity  { return c.severity }
+
+// Evaluate runs the program on the response. A program that fails or
+// prints an invalid result is reported as a warning of the check.
+func (c *commandCheck) Evaluate(ctx context.Context, resp *http.Response, body []byte) (HeaderResult, bool) {
+	response := commandResponse{StatusCode: resp.StatusCode, Protocol: resp.Proto, Headers: resp.Header, Body: body}
+	if resp.Request != nil {
+		response.URL = resp.Request.URL.String()
+	}
+	input, err := json.Marshal(response)
+	if err != nil {
+		return HeaderResult{}, false
+	}
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+	output, err := runCommand(ctx, c.path, input)
+	failed := HeaderResult{Name: c.id, Status: StatusMisconfigured}
+	if err != nil {
+		failed.Warnings = []string{fmt.Sprintf("plugin failed: %v", err)}
+		return failed, true
+	}
+	if len(bytes.TrimSpace(output)) == 0 {
+		return HeaderResult{}, false
+	}
+
+	var result HeaderResult
+	if err := json.Unmarshal(output, &result); err != nil {
+		failed.Warnings = []string{fmt.Sprintf("plugin printed an invalid result: %v", err)}
+		return failed, true
+	}
+	switch result.Status {
+	case StatusPresent, StatusMisconfigured, StatusReportOnly, StatusDeprecated, StatusDisclosure:
+		result.Present = true
+	case StatusMissing:
+		result.Present = false
+	default:
+		failed.Warnings = []string{fmt.Sprintf("plugin printed an unknown status %q", result.Status)}
+		return failed, true
+	}
+	return result, true
+}
+
+// runCommand runs a program with input on its standard input, returning its
+// standard output, or its standard error when it fails
+func runCommand(ctx context.Context, path string, input []byte, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, errors.New(message)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,219 @@
+package scanner
+
+import (
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// policyRule declares the value a header is expected to have
+type policyRule struct {
+	// Value is the exact expected value, ignoring case
+	Value string `yaml:"value"`
+	// Pattern is a regular expression the value must match
+	Pattern string `yaml:"pattern"`
+	// Severity overrides the severity of the header's findings
+	Severity string `yaml:"severity"`
+
+	pattern *regexp.Regexp
+}
+
+// policy is a policy-as-code file declaring the headers every response must
+// and must not send
+type policy struct {
+	// Required headers are checked in addition to the default ones
+	Required []string `yaml:"required"`
+	// Headers map header names to the values they must have. Headers with
+	// a rule are required too.
+	Headers map[string]policyRule `yaml:"headers"`
+	// Forbidden headers must not be sent and are reported as disclosures
+	Forbidden []string `yaml:"forbidden"`
+	// Overrides scope exceptions and extra rules to the URLs they match
+	Overrides []policyOverride `yaml:"overrides"`
+	// Expressions are CEL rules evaluated against every response
+	Expressions []expressionRule `yaml:"expressions"`
+}
+
+// policyOverride adjusts the policy for the URLs matching a pattern
+type policyOverride struct {
+	// Match is a glob where * matches any characters. Patterns starting
+	// with / match the URL path, others the whole URL.
+	Match string `yaml:"match"`
+	// Exempt headers are not reported for matching URLs, whatever their
+	// status
+	Exempt []string `yaml:"exempt"`
+	// Headers replace the rules of the policy for matching URLs
+	Headers map[string]policyRule `yaml:"headers"`
+
+	match *regexp.Regexp
+}
+
+// compileRules canonicalizes the header names of the rules and compiles
+// their patterns
+func compileRules(rules map[string]policyRule) (map[string]policyRule, error) {
+	compiled := make(map[string]policyRule)
+	for name, rule := range rules {
+		name = http.CanonicalHeaderKey(name)
+		if rule.Pattern != "" {
+			var err error
+			if rule.pattern, err = regexp.Compile(rule.Pattern); err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+		}
+		compiled[name] = rule
+	}
+	return compiled, nil
+}
+
+// globPattern compiles a glob where * matches any characters and ? a
+// single one
+func globPattern(glob string) (*regexp.Regexp, error) {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			pattern.WriteString(".*")
+		case '?':
+			pattern.WriteString(".")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	pattern.WriteString("$")
+	return regexp.Compile(pattern.String())
+}
+
+// matches reports whether the override applies to the URL
+func (o policyOverride) matches(u *neturl.URL) bool {
+	if strings.HasPrefix(o.Match, "/") {
+		return o.match.MatchString(u.Path)
+	}
+	return o.match.MatchString(u.String())
+}
+
+// activePolicy is the policy loaded with --policy, if any
+var activePolicy *policy
+
+// LoadPolicy loads a YAML or JSON policy file and adds its required and
+// forbidden headers and severities to the built-in ones
+func LoadPolicy(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	var p policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return err
+	}
+
+	rules, err := compileRules(p.Headers)
+	if err != nil {
+		return err
+	}
+	for name, rule := range rules {
+		if rule.Severity != "" {
+			severity, err := parseSeverity(rule.Severity)
+			if err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+			headerSeverities[name] = severity
+		}
+	}
+	p.Headers = rules
+	for i, override := range p.Overrides {
+		if p.Overrides[i].match, err = globPattern(override.Match); err != nil {
+			return fmt.Errorf("override %s: %v", override.Match, err)
+		}
+		if p.Overrides[i].Headers, err = compileRules(override.Headers); err != nil {
+			return fmt.Errorf("override %s: %v", override.Match, err)
+		}
+		p.Overrides[i].Exempt = ParseHeaderList(strings.Join(override.Exempt, ","))
+	}
+	if err := compileExpressions(p.Expressions); err != nil {
+		return err
+	}
+
+	var ruled []string
+	for name := range rules {
+		ruled = append(ruled, name)
+	}
+	sort.Strings(ruled)
+	for _, header := range append(ParseHeaderList(strings.Join(p.Required, ",")), ruled...) {
+		RequireHeader(header)
+	}
+
+	var forbidden []flaggedHeader
+	for _, name := range p.Forbidden {
+		forbidden = append(forbidden, flaggedHeader{http.CanonicalHeaderKey(name), "the policy forbids this header"})
+	}
+	addDisclosureHeaders(forbidden)
+
+	activePolicy = &p
+	return nil
+}
+
+// checkPolicy reports the header results whose values break the rules and
+// expressions of the active policy for the target, dropping the results its
+// overrides exempt
+func checkPolicy(t Target, resp *http.Response, results []HeaderResult) []HeaderResult {
+	if activePolicy == nil {
+		return results
+	}
+	results = checkExpressions(activePolicy.Expressions, resp, results)
+	rules := activePolicy.Headers
+	exempt := make(map[string]bool)
+	if u, err := neturl.Parse(normalizeURL(t.URL)); err == nil {
+		for _, override := range activePolicy.Overrides {
+			if !override.matches(u) {
+				continue
+			}
+			for _, name := range override.Exempt {
+				exempt[name] = true
+			}
+			if len(override.Headers) > 0 {
+				merged := make(map[string]policyRule)
+				for name, rule := range rules {
+					merged[name] = rule
+				}
+				for name, rule := range override.Headers {
+					merged[name] = rule
+				}
+				rules = merged
+			}
+		}
+	}
+
+	kept := results[:0]
+	for _, result := range results {
+		if !exempt[result.Name] {
+			kept = append(kept, result)
+		}
+	}
+	results = kept
+
+	for i := range results {
+		result := &results[i]
+		rule, ok := rules[result.Name]
+		if !ok || (result.Status != StatusPresent && result.Status != StatusMisconfigured) {
+			continue
+		}
+		value := strings.TrimSpace(result.Value)
+		switch {
+		case rule.Value != "" && !strings.EqualFold(value, rule.Value):
+			result.Issues = append(result.Issues, fmt.Sprintf("policy expects %q, got %q", rule.Value, value))
+		case rule.pattern != nil && !rule.pattern.MatchString(value):
+			result.Issues = append(result.Issues, fmt.Sprintf("policy expects a value matching %s, got %q", rule.Pattern, value))
+		default:
+			continue
+		}
+		result.Status = StatusMisconfigured
+	}
+	return results
+}
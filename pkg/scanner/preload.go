@@ -0,0 +1,167 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// PreloadChecks enables verifying that domains claiming HSTS preload are
+// on the preload list
+var PreloadChecks bool
+
+// preloadStatusURL is the hstspreload.org API used when no snapshot of the
+// preload list is loaded
+const preloadStatusURL = "https://hstspreload.org/api/v2/status?domain="
+
+// preloadEntry is an entry of the Chromium HSTS preload list
+type preloadEntry struct {
+	Name              string `json:"name"`
+	Mode              string `json:"mode"`
+	IncludeSubdomains bool   `json:"include_subdomains"`
+}
+
+var (
+	// preloadSnapshot holds the entries of a local preload list snapshot,
+	// keyed by domain, or nil to query hstspreload.org
+	preloadSnapshot map[string]preloadEntry
+
+	// preloadCache remembers the status of each domain already looked up,
+	// guarded by preloadMu since targets are scanned concurrently
+	preloadCache = make(map[string]string)
+	preloadMu    sync.Mutex
+)
+
+// LoadPreloadSnapshot loads a snapshot of the Chromium preload list, the
+// transport_security_state_static.json file, whose lines may be // comments
+func LoadPreloadSnapshot(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	var stripped bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		if !strings.HasPrefix(strings.TrimSpace(scanner.Text()), "//") {
+			stripped.Write(scanner.Bytes())
+			stripped.WriteByte('\n')
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	var list struct {
+		Entries []preloadEntry `json:"entries"`
+	}
+	if err := json.Unmarshal(stripped.Bytes(), &list); err != nil {
+		return err
+	}
+	preloadSnapshot = make(map[string]preloadEntry)
+	for _, entry := range list.Entries {
+		if entry.Mode == "force-https" {
+			preloadSnapshot[strings.ToLower(entry.Name)] = entry
+		}
+	}
+	return nil
+}
+
+// preloadStatus returns whether the domain is "preloaded", "pending" or
+// "unknown" to the preload list
+func preloadStatus(ctx context.Context, c *http.Client, domain string) (string, error) {
+	preloadMu.Lock()
+	status, ok := preloadCache[domain]
+	preloadMu.Unlock()
+	if ok {
+		return status, nil
+	}
+
+	status = "unknown"
+	if preloadSnapshot != nil {
+		// Parent domains preloaded with include_subdomains cover the domain
+		for name := domain; name != ""; {
+			if entry, ok := preloadSnapshot[name]; ok && (name == domain || entry.IncludeSubdomains) {
+				status = "preloaded"
+				break
+			}
+			_, name, _ = strings.Cut(name, ".")
+		}
+	} else {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, preloadStatusURL+neturl.QueryEscape(domain), nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := c.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("hstspreload.org returned %s", resp.Status)
+		}
+		var body struct {
+			Status string `json:"status"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return "", err
+		}
+		status = body.Status
+	}
+	preloadMu.Lock()
+	preloadCache[domain] = status
+	preloadMu.Unlock()
+	return status, nil
+}
+
+// checkPreload verifies the preload claim of a Strict-Transport-Security
+// result, reporting whether the domain is on the preload list and whether
+// the policy meets the submission requirements of hstspreload.org
+func checkPreload(ctx context.Context, c *http.Client, u *neturl.URL, result *HeaderResult) {
+	// Browsers only use the first value, and validateHSTS already reported
+	// the problems of the policy
+	value := result.Value
+	if len(result.Values) > 0 {
+		value = result.Values[0]
+	}
+	var ignored findings
+	if !parseHSTS(value, &ignored).Preload {
+		return
+	}
+
+	domain := strings.ToLower(u.Hostname())
+	status, err := preloadStatus(ctx, c, domain)
+	switch {
+	case err != nil:
+		result.Warnings = append(result.Warnings, fmt.Sprintf("could not check the preload list: %v", err))
+	case status == "preloaded":
+	case status == "pending":
+		result.Warnings = append(result.Warnings, domain+" is pending inclusion in the preload list")
+	default:
+		result.Warnings = append(result.Warnings, "preload is set but "+domain+" is not on the preload list")
+	}
+	if status == "preloaded" {
+		return
+	}
+
+	// Submission requirements of hstspreload.org not already covered by the
+	// preload warnings of validateHSTS
+	if u.Scheme != "https" {
+		result.Warnings = append(result.Warnings, "preload submission requires the header to be served over HTTPS")
+	}
+	if net.ParseIP(domain) != nil {
+		result.Warnings = append(result.Warnings, "preload submission requires a domain name, not an IP address")
+	} else if registrable, err := publicsuffix.EffectiveTLDPlusOne(domain); err == nil && registrable != domain {
+		result.Warnings = append(result.Warnings, "preload submission must be made for the registrable domain "+registrable)
+	}
+}
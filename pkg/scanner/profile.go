@@ -0,0 +1,222 @@
+package scanner
+
+import (
+	"sort"
+	"strings"
+)
+
+// checkProfile is a set of header recommendations that extends the
+// built-in checks
+type checkProfile struct {
+	// Required headers are checked in addition to the default ones
+	Required []string
+	// Exempt headers are no longer required
+	Exempt []string
+	// Sensitive treats every target as sensitive, so responses must not be
+	// cached
+	Sensitive bool
+	// Checks flag values that differ from the profile's recommendations
+	Checks map[string]func(value string, f *findings)
+	// Remove lists headers that should not be sent, reported as disclosures
+	Remove []flaggedHeader
+}
+
+// profiles maps each selectable profile to its recommendations
+var profiles = map[string]checkProfile{
+	"default":     {},
+	"owasp":       owaspProfile,
+	"api":         apiProfile,
+	"static-site": staticSiteProfile,
+	"webapp":      webappProfile,
+	"admin-panel": adminPanelProfile,
+}
+
+// ProfileNames returns the selectable profiles in sorted order
+func ProfileNames() []string {
+	var names []string
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sensitiveProfile is set when the selected profile treats every target
+// as sensitive
+var sensitiveProfile bool
+
+// profileChecks holds the checks of the selected profile, which run after
+// the built-in validators
+var profileChecks map[string]func(value string, f *findings)
+
+// ApplyProfile selects a profile, adding its required headers, checks and
+// headers to remove to the built-in ones
+func ApplyProfile(name string) bool {
+	profile, ok := profiles[name]
+	if !ok {
+		return false
+	}
+	for _, header := range profile.Required {
+		RequireHeader(header)
+	}
+	for _, header := range profile.Exempt {
+		Disable(header)
+	}
+	sensitiveProfile = profile.Sensitive
+	profileChecks = profile.Checks
+	addDisclosureHeaders(profile.Remove)
+	return true
+}
+
+// addDisclosureHeaders reports more headers as disclosures, skipping those
+// already flagged
+func addDisclosureHeaders(headers []flaggedHeader) {
+	known := make(map[string]bool)
+	for _, header := range append(deprecatedHeaders, disclosureHeaders...) {
+		known[header.Name] = true
+	}
+	for _, header := range headers {
+		if !known[header.Name] {
+			known[header.Name] = true
+			disclosureHeaders = append(disclosureHeaders, header)
+		}
+	}
+}
+
+// owaspProfile follows the OWASP Secure Headers Project recommendations
+var owaspProfile = checkProfile{
+	Required: []string{"X-Permitted-Cross-Domain-Policies"},
+	Checks: map[string]func(value string, f *findings){
+		"Content-Security-Policy":      owaspCSP,
+		"X-Frame-Options":              owaspRecommend("deny"),
+		"Referrer-Policy":              owaspRecommend("no-referrer"),
+		"Cross-Origin-Opener-Policy":   owaspRecommend("same-origin"),
+		"Cross-Origin-Embedder-Policy": owaspRecommend("require-corp"),
+		"Cross-Origin-Resource-Policy": owaspRecommend("same-origin"),
+	},
+	Remove: []flaggedHeader{
+		{"X-Runtime", "reveals request timing of the application framework"},
+		{"X-Version", "reveals the application version"},
+		{"X-Backend-Server", "reveals the internal backend server"},
+		{"X-Drupal-Cache", "reveals the Drupal CMS"},
+		{"X-Drupal-Dynamic-Cache", "reveals the Drupal CMS"},
+		{"X-Powered-CMS", "reveals the CMS"},
+		{"X-Redirect-By", "reveals the software that issued the redirect"},
+		{"X-SourceFiles", "reveals source file paths of the ASP.NET application"},
+		{"X-Envoy-Upstream-Service-Time", "reveals the Envoy proxy"},
+		{"X-Turbo-Charged-By", "reveals the server software"},
+		{"X-Varnish", "reveals the Varnish cache"},
+		{"Liferay-Portal", "reveals the Liferay portal"},
+		{"X-Nextjs-Cache", "reveals the Next.js framework"},
+	},
+}
+
+// owaspRecommend returns a check that warns when the header value is not
+// the one recommended by OWASP, ignoring case and parameters
+func owaspRecommend(recommended string) func(value string, f *findings) {
+	return recommend("OWASP", recommended)
+}
+
+// recommend returns a check that warns when the header value is not the
+// one recommended by source, ignoring case and parameters
+func recommend(source, recommended string) func(value string, f *findings) {
+	return func(value string, f *findings) {
+		if token, _, _ := strings.Cut(value, ";"); !strings.EqualFold(strings.TrimSpace(token), recommended) {
+			f.warn("%s recommends %s", source, recommended)
+		}
+	}
+}
+
+// owaspCSPDirectives are the directives of the policy recommended by OWASP
+var owaspCSPDirectives = []string{"default-src", "form-action", "base-uri", "object-src", "frame-ancestors", "upgrade-insecure-requests"}
+
+// owaspCSP warns about directives of the OWASP recommended policy, which
+// restricts everything to 'self', that are missing from the policy
+func owaspCSP(value string, f *findings) {
+	recommendCSP("OWASP", owaspCSPDirectives...)(value, f)
+}
+
+// recommendCSP returns a check that warns about the directives recommended
+// by source that no policy of the Content-Security-Policy sets
+func recommendCSP(source string, directives ...string) func(value string, f *findings) {
+	return func(value string, f *findings) {
+		policies := parseCSPList(value)
+		for _, name := range directives {
+			found := false
+			for _, policy := range policies {
+				if _, ok := policy.directive(name); ok {
+					found = true
+				}
+			}
+			if !found {
+				f.warn("%s recommends setting %s", source, name)
+			}
+		}
+	}
+}
+
+// frameAncestorsNone warns when the Content-Security-Policy lets any site
+// frame the response, for responses that are never meant to be framed
+func frameAncestorsNone(source string) func(value string, f *findings) {
+	return func(value string, f *findings) {
+		for _, policy := range parseCSPList(value) {
+			if directive, ok := policy.directive("frame-ancestors"); ok && len(directive.Sources) == 1 && directive.has("'none'") {
+				return
+			}
+		}
+		f.warn("%s recommends frame-ancestors 'none'", source)
+	}
+}
+
+// apiProfile suits API backends, whose JSON responses are not rendered as
+// documents: browser features such as Permissions-Policy and cross-origin
+// isolation do not apply, but responses must never be framed or cached
+var apiProfile = checkProfile{
+	Exempt: []string{
+		"Permissions-Policy",
+		"Cross-Origin-Opener-Policy",
+		"Cross-Origin-Embedder-Policy",
+	},
+	Sensitive: true,
+	Checks: map[string]func(value string, f *findings){
+		"Content-Security-Policy": frameAncestorsNone("the api profile"),
+		"X-Frame-Options":         recommend("the api profile", "deny"),
+		"Referrer-Policy":         recommend("the api profile", "no-referrer"),
+	},
+}
+
+// staticSiteProfile suits static sites without sessions or cross-origin
+// isolation needs, whose resources may be loaded by other sites
+var staticSiteProfile = checkProfile{
+	Exempt: []string{
+		"Cross-Origin-Opener-Policy",
+		"Cross-Origin-Embedder-Policy",
+	},
+	Checks: map[string]func(value string, f *findings){
+		"Content-Security-Policy": recommendCSP("the static-site profile", "default-src", "base-uri", "form-action", "frame-ancestors"),
+	},
+}
+
+// webappProfile suits browser-facing applications with user sessions,
+// which should isolate their browsing context and control framing in CSP
+var webappProfile = checkProfile{
+	Checks: map[string]func(value string, f *findings){
+		"Content-Security-Policy":    recommendCSP("the webapp profile", "frame-ancestors", "form-action"),
+		"Cross-Origin-Opener-Policy": recommend("the webapp profile", "same-origin"),
+	},
+}
+
+// adminPanelProfile suits administrative interfaces, which warrant the
+// strictest settings: nothing is cached, framed or leaked to other sites
+var adminPanelProfile = checkProfile{
+	Required:  []string{"X-Permitted-Cross-Domain-Policies"},
+	Sensitive: true,
+	Checks: map[string]func(value string, f *findings){
+		"Content-Security-Policy":      frameAncestorsNone("the admin-panel profile"),
+		"X-Frame-Options":              recommend("the admin-panel profile", "deny"),
+		"Referrer-Policy":              recommend("the admin-panel profile", "no-referrer"),
+		"Cross-Origin-Opener-Policy":   recommend("the admin-panel profile", "same-origin"),
+		"Cross-Origin-Embedder-Policy": recommend("the admin-panel profile", "require-corp"),
+		"Cross-Origin-Resource-Policy": recommend("the admin-panel profile", "same-origin"),
+	},
+}
@@ -0,0 +1,41 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+// proxySchemes are the proxy URL schemes the transport supports
+var proxySchemes = map[string]bool{"http": true, "https": true, "socks5": true, "socks5h": true}
+
+// ProxyFunc returns the transport's proxy selection. --proxy takes an
+// http://, https:// or socks5:// URL and --socks5 a SOCKS5 host:port, and
+// without either the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment
+// variables apply.
+func ProxyFunc(proxy, socks5 string) (func(*http.Request) (*neturl.URL, error), error) {
+	if proxy != "" && socks5 != "" {
+		return nil, errors.New("--proxy and --socks5 cannot be combined")
+	}
+	if socks5 != "" {
+		proxy = "socks5://" + strings.TrimPrefix(socks5, "socks5://")
+	}
+	if proxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	u, err := neturl.Parse(proxy)
+	if err != nil || u.Host == "" {
+		// Like curl, a bare host:port is an HTTP proxy
+		u, err = neturl.Parse("http://" + proxy)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !proxySchemes[u.Scheme] {
+		return nil, fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+	}
+	return http.ProxyURL(u), nil
+}
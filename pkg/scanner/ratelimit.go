@@ -0,0 +1,142 @@
+package scanner
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenBucket is a token bucket refilled at a fixed rate, holding at most
+// burst tokens. Each request takes a token, waiting for one when the
+// bucket is empty.
+type tokenBucket struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    float64
+	tokens   float64
+	last     time.Time
+}
+
+// newTokenBucket returns a full bucket refilled with rate tokens per second
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		interval: time.Duration(float64(time.Second) / rate),
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+// wait takes a token, sleeping until one is available or ctx is done, in
+// which case the token is returned
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += float64(now.Sub(b.last)) / float64(b.interval)
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	// Taking the token now leaves the bucket in debt until it is earned
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens * float64(b.interval))
+	}
+	b.mu.Unlock()
+	if err := sleep(ctx, delay); err != nil {
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// sleep waits for the delay, or returns the error of ctx once it is done
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// RateLimiter delays requests to stay under a global and a per-host rate,
+// adding random jitter so requests do not arrive in a detectable rhythm
+type RateLimiter struct {
+	global   *tokenBucket
+	hostRate float64
+	jitter   time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*tokenBucket
+}
+
+// NewRateLimiter limits requests to rate per second overall and hostRate
+// per second for each host, where zero is unlimited
+func NewRateLimiter(rate, hostRate float64, jitter time.Duration) *RateLimiter {
+	l := &RateLimiter{
+		hostRate: hostRate,
+		jitter:   jitter,
+		hosts:    make(map[string]*tokenBucket),
+	}
+	if rate > 0 {
+		l.global = newTokenBucket(rate, 1)
+	}
+	return l
+}
+
+// wait waits for the host and global limits before a request to host,
+// until ctx is done
+func (l *RateLimiter) wait(ctx context.Context, host string) error {
+	if l.hostRate > 0 {
+		host = strings.ToLower(host)
+		l.mu.Lock()
+		bucket, ok := l.hosts[host]
+		if !ok {
+			bucket = newTokenBucket(l.hostRate, 1)
+			l.hosts[host] = bucket
+		}
+		l.mu.Unlock()
+		if err := bucket.wait(ctx); err != nil {
+			return err
+		}
+	}
+	if l.global != nil {
+		if err := l.global.wait(ctx); err != nil {
+			return err
+		}
+	}
+	if l.jitter > 0 {
+		return sleep(ctx, time.Duration(rand.Int63n(int64(l.jitter))))
+	}
+	return nil
+}
+
+// RateLimitedTransport sends the requests of next once the limiter allows
+// them, so transports sharing a limiter share its limits
+type RateLimitedTransport struct {
+	Next    http.RoundTripper
+	Limiter *RateLimiter
+}
+
+// RoundTrip waits for the limiter before sending the request, including
+// each redirect
+func (t *RateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.Limiter.wait(req.Context(), req.URL.Host); err != nil {
+		// RoundTrip closes the body even when it doesn't send the request
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.Next.RoundTrip(req)
+}
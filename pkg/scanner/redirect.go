@@ -0,0 +1,124 @@
+package scanner
+
+import (
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+// Hop is a redirect response on the way to the final response
+type Hop struct {
+	URL      string `json:"url" yaml:"url"`
+	Status   int    `json:"status" yaml:"status"`
+	Location string `json:"location" yaml:"location"`
+	// Upgrade reports whether the hop redirects from HTTP to HTTPS
+	Upgrade bool `json:"upgrade,omitempty" yaml:"upgrade,omitempty"`
+	// Headers lists the required headers the hop sent
+	Headers []string `json:"headers,omitempty" yaml:"headers,omitempty"`
+}
+
+// redirectChain returns the redirect responses that led to the final
+// response, in the order they were followed
+func redirectChain(resp *http.Response) []Hop {
+	var chain []Hop
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		hop := req.Response
+		if hop.Request == nil {
+			break
+		}
+		h := Hop{
+			URL:      hop.Request.URL.String(),
+			Status:   hop.StatusCode,
+			Location: req.URL.String(),
+			Upgrade:  hop.Request.URL.Scheme == "http" && req.URL.Scheme == "https",
+		}
+		for _, header := range RequiredHeaders() {
+			if hasHeader(hop.Header, header) {
+				h.Headers = append(h.Headers, header)
+			}
+		}
+		chain = append([]Hop{h}, chain...)
+	}
+	return chain
+}
+
+// checkRedirects reports the redirects that downgrade to HTTP or upgrade
+// to HTTPS on another host, and warns about required headers that only
+// redirect responses sent, which browsers apply to the redirect but not to
+// the page. It reports false when the response was not redirected.
+func checkRedirects(resp *http.Response, results []HeaderResult) (HeaderResult, bool) {
+	chain := redirectChain(resp)
+	if len(chain) == 0 {
+		return HeaderResult{}, false
+	}
+
+	var f findings
+	for _, hop := range chain {
+		from, err := neturl.Parse(hop.URL)
+		if err != nil {
+			continue
+		}
+		to, err := neturl.Parse(hop.Location)
+		if err != nil {
+			continue
+		}
+		switch {
+		case from.Scheme == "https" && to.Scheme == "http":
+			f.issue("%s redirects to HTTP at %s", hop.URL, hop.Location)
+		case hop.Upgrade && !strings.EqualFold(from.Hostname(), to.Hostname()):
+			f.warn("%s redirects to HTTPS on another host, so %s itself never sets Strict-Transport-Security", hop.URL, from.Hostname())
+		}
+	}
+
+	for i := range results {
+		if results[i].Status != StatusMissing {
+			continue
+		}
+		for _, hop := range chain {
+			if hasString(hop.Headers, results[i].Name) {
+				results[i].Warnings = append(results[i].Warnings, "only sent by the redirect from "+hop.URL+", not by the final response")
+				break
+			}
+		}
+	}
+
+	result := HeaderResult{
+		Name:     "Redirect-Chain",
+		Present:  true,
+		Status:   StatusPresent,
+		Issues:   f.Issues,
+		Warnings: f.Warnings,
+	}
+	if len(f.Issues) > 0 {
+		result.Status = StatusMisconfigured
+	}
+	return result, true
+}
+
+// hasString reports whether the list contains the string, ignoring case
+func hasString(list []string, s string) bool {
+	for _, element := range list {
+		if strings.EqualFold(element, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// String summarizes the hop, e.g. "301 http://example.com/ ->
+// https://example.com/ (HTTP to HTTPS)"
+func (h Hop) String() string {
+	var notes []string
+	if h.Upgrade {
+		notes = append(notes, "HTTP to HTTPS")
+	}
+	if len(h.Headers) > 0 {
+		notes = append(notes, "sends "+strings.Join(h.Headers, ", "))
+	}
+	s := fmt.Sprintf("%d %s -> %s", h.Status, h.URL, h.Location)
+	if len(notes) > 0 {
+		s += " (" + strings.Join(notes, "; ") + ")"
+	}
+	return s
+}
@@ -0,0 +1,223 @@
+package scanner
+
+import (
+	"encoding/json"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+// ReportingChecks enables the optional checks of the reporting headers
+var ReportingChecks bool
+
+// reportToGroup is a single endpoint group of the Report-To header
+type reportToGroup struct {
+	Group     string `json:"group"`
+	MaxAge    *int64 `json:"max_age"`
+	Endpoints []struct {
+		URL string `json:"url"`
+	} `json:"endpoints"`
+}
+
+// nelPolicy is the Network Error Logging policy of the NEL header
+type nelPolicy struct {
+	ReportTo        string   `json:"report_to"`
+	MaxAge          *int64   `json:"max_age"`
+	SuccessFraction *float64 `json:"success_fraction"`
+	FailureFraction *float64 `json:"failure_fraction"`
+}
+
+// checkReporting validates the Reporting-Endpoints, Report-To and NEL
+// headers that were sent, and checks that the CSP reporting directives in
+// the results reference a declared endpoint
+func checkReporting(headers http.Header, results []HeaderResult) []HeaderResult {
+	// Endpoints from both headers can be referenced by CSP report-to
+	endpoints := make(map[string]bool)
+
+	if hasHeader(headers, "Reporting-Endpoints") {
+		var f findings
+		for _, name := range parseReportingEndpoints(strings.Join(headers.Values("Reporting-Endpoints"), ","), &f) {
+			endpoints[name] = true
+		}
+		results = append(results, reportingResult("Reporting-Endpoints", headers, f))
+	}
+
+	groups := make(map[string]bool)
+	if hasHeader(headers, "Report-To") {
+		var f findings
+		for _, name := range parseReportTo(strings.Join(headers.Values("Report-To"), ","), &f) {
+			groups[name] = true
+			endpoints[name] = true
+		}
+		if !hasHeader(headers, "NEL") {
+			f.warn("Report-To is deprecated, use Reporting-Endpoints unless NEL needs it")
+		}
+		results = append(results, reportingResult("Report-To", headers, f))
+	}
+
+	if hasHeader(headers, "NEL") {
+		var f findings
+		validateNEL(headers.Get("NEL"), groups, &f)
+		results = append(results, reportingResult("NEL", headers, f))
+	}
+
+	for i, result := range results {
+		if result.Name == "Content-Security-Policy" && result.Value != "" {
+			var f findings
+			validateCSPReporting(result.Value, endpoints, &f)
+			results[i].Warnings = append(results[i].Warnings, f.Warnings...)
+		}
+	}
+	return results
+}
+
+// reportingResult builds the result of a reporting header from its findings
+func reportingResult(name string, headers http.Header, f findings) HeaderResult {
+	result := HeaderResult{
+		Name:     name,
+		Present:  true,
+		Status:   StatusPresent,
+		Issues:   f.Issues,
+		Warnings: f.Warnings,
+	}
+	result.setValues(headers.Values(name))
+	if len(f.Issues) > 0 {
+		result.Status = StatusMisconfigured
+	}
+	return result
+}
+
+// validateReportingURL checks an endpoint URL, which browsers only report
+// to when it is absolute and served over HTTPS
+func validateReportingURL(name, endpoint string, f *findings) {
+	u, err := neturl.Parse(endpoint)
+	switch {
+	case err != nil || !u.IsAbs():
+		f.issue("endpoint %s has invalid URL %q", name, endpoint)
+	case u.Scheme != "https":
+		f.issue("endpoint %s is not HTTPS, so browsers will not send reports to it", name)
+	}
+}
+
+// parseReportingEndpoints parses the Reporting-Endpoints dictionary and
+// returns the declared endpoint names
+func parseReportingEndpoints(value string, f *findings) []string {
+	p := &sfParser{s: value}
+	var names []string
+	for {
+		p.skipSpace()
+		if p.done() {
+			break
+		}
+		name, err := p.key()
+		if err != nil {
+			f.issue("invalid syntax makes browsers ignore the header: %v", err)
+			break
+		}
+		if !p.consume('=') {
+			f.issue("endpoint %s has no URL", name)
+		} else if item, err := p.item(); err != nil {
+			f.issue("invalid syntax makes browsers ignore the header: %v", err)
+			break
+		} else if !strings.HasPrefix(item, `"`) {
+			f.issue("endpoint %s URL must be a quoted string", name)
+		} else {
+			validateReportingURL(name, strings.Trim(item, `"`), f)
+			names = append(names, name)
+		}
+
+		p.skipSpace()
+		if !p.done() && !p.consume(',') {
+			f.issue("invalid syntax makes browsers ignore the header: expected ',' at position %d", p.pos)
+			break
+		}
+	}
+	if len(names) == 0 && len(f.Issues) == 0 {
+		f.issue("no endpoints are declared")
+	}
+	return names
+}
+
+// parseReportTo parses the Report-To header, a comma-separated list of JSON
+// objects, and returns the declared group names
+func parseReportTo(value string, f *findings) []string {
+	var groups []reportToGroup
+	if err := json.Unmarshal([]byte("["+value+"]"), &groups); err != nil {
+		f.issue("invalid JSON makes browsers ignore the header: %v", err)
+		return nil
+	}
+
+	var names []string
+	for _, group := range groups {
+		if group.Group == "" {
+			group.Group = "default"
+		}
+		if group.MaxAge == nil {
+			f.issue("group %s is missing max_age", group.Group)
+		}
+		if len(group.Endpoints) == 0 {
+			f.issue("group %s has no endpoints", group.Group)
+		}
+		for _, endpoint := range group.Endpoints {
+			validateReportingURL(group.Group, endpoint.URL, f)
+		}
+		names = append(names, group.Group)
+	}
+	return names
+}
+
+// validateNEL checks the Network Error Logging policy, which can only
+// report to a group declared in Report-To
+func validateNEL(value string, groups map[string]bool, f *findings) {
+	var policy nelPolicy
+	if err := json.Unmarshal([]byte(value), &policy); err != nil {
+		f.issue("invalid JSON makes browsers ignore the header: %v", err)
+		return
+	}
+	switch {
+	case policy.ReportTo == "":
+		f.issue("report_to is missing")
+	case !groups[policy.ReportTo]:
+		f.issue("report_to group %s is not declared in Report-To", policy.ReportTo)
+	}
+	switch {
+	case policy.MaxAge == nil:
+		f.issue("max_age is missing")
+	case *policy.MaxAge == 0:
+		f.warn("max_age=0 removes the policy")
+	}
+	if fraction := policy.SuccessFraction; fraction != nil && (*fraction < 0 || *fraction > 1) {
+		f.issue("success_fraction must be between 0 and 1")
+	}
+	if fraction := policy.FailureFraction; fraction != nil && (*fraction < 0 || *fraction > 1) {
+		f.issue("failure_fraction must be between 0 and 1")
+	}
+}
+
+// validateCSPReporting checks that the report-to directive of a CSP names a
+// declared endpoint, and warns about the deprecated report-uri directive
+func validateCSPReporting(value string, endpoints map[string]bool, f *findings) {
+	for _, policy := range parseCSPList(value) {
+		validateCSPPolicyReporting(policy, endpoints, f)
+	}
+}
+
+// validateCSPPolicyReporting checks the reporting directives of one policy
+func validateCSPPolicyReporting(policy cspPolicy, endpoints map[string]bool, f *findings) {
+	reportTo, hasReportTo := policy.directive("report-to")
+	for _, name := range reportTo.Sources {
+		if !endpoints[name] {
+			f.warn("report-to endpoint %s is not declared in Reporting-Endpoints or Report-To", name)
+		}
+	}
+	if reportURI, ok := policy.directive("report-uri"); ok {
+		if !hasReportTo {
+			f.warn("report-uri is deprecated, add report-to with a Reporting-Endpoints endpoint")
+		}
+		for _, endpoint := range reportURI.Sources {
+			if _, err := neturl.Parse(endpoint); err != nil {
+				f.warn("report-uri has invalid URL %q", endpoint)
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package scanner
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// RepeatedFlag is a flag that can be given more than once, collecting each
+// value. Config file lists set it once per item.
+type RepeatedFlag []string
+
+// RepeatableFlag is implemented by the flags that can be repeated
+type RepeatableFlag interface {
+	flag.Value
+	repeatable()
+}
+
+func (r *RepeatedFlag) repeatable() {}
+
+func (r *RepeatedFlag) String() string {
+	return strings.Join(*r, ", ")
+}
+
+func (r *RepeatedFlag) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
+// HeaderFlag collects "Name: value" request headers
+type HeaderFlag struct {
+	RepeatedFlag
+}
+
+func (h *HeaderFlag) Set(value string) error {
+	name, _, ok := strings.Cut(value, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("header %q must be formatted as Name: value", value)
+	}
+	return h.RepeatedFlag.Set(value)
+}
+
+// RequestHeaders are the custom headers sent with every request, such as
+// API keys or feature flags needed to reach the application behind a
+// gateway
+var RequestHeaders HeaderFlag
+
+// setRequestHeaders adds the custom headers to a request, replacing the
+// defaults of the same name. A Host header sets the request host instead.
+func setRequestHeaders(req *http.Request) {
+	custom := make(http.Header)
+	for _, header := range RequestHeaders.RepeatedFlag {
+		name, value, _ := strings.Cut(header, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		custom.Add(name, value)
+	}
+	for name, values := range custom {
+		req.Header[name] = values
+	}
+}
+
+var (
+	// RequestBody is the --data sent with every request
+	RequestBody []byte
+	// RequestContentType is the Content-Type of the request body
+	RequestContentType string
+)
+
+// LoadRequestData returns a --data value, reading it from a file when it
+// starts with @ as with curl, e.g. @body.json
+func LoadRequestData(value string) ([]byte, error) {
+	if filePath, ok := strings.CutPrefix(value, "@"); ok {
+		return os.ReadFile(filePath)
+	}
+	return []byte(value), nil
+}
+
+// ValidMethod reports whether a method is a valid HTTP token
+func ValidMethod(method string) bool {
+	return method != "" && strings.IndexFunc(method, func(r rune) bool {
+		return r <= ' ' || r >= 0x7f || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r)
+	}) < 0
+}
+
+// idempotent reports whether requests of a method can be sent again
+// without changing more than the first one did (RFC 9110 section 9.2.2),
+// so that failures can be retried
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// newRequest returns a request with the --data body, if any. The body can
+// be sent again for retries and redirects that preserve the method.
+func newRequest(method, url string) (*http.Request, error) {
+	if len(RequestBody) == 0 {
+		return http.NewRequest(method, url, nil)
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(RequestBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", RequestContentType)
+	return req, nil
+}
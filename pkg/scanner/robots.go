@@ -0,0 +1,159 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// RespectRobots enables skipping the targets robots.txt disallows
+var RespectRobots bool
+
+// robotsAgent is the product token matched against robots.txt user-agent
+// lines
+const robotsAgent = "gosecurityheaders"
+
+// robotsRule is an Allow or Disallow rule of robots.txt
+type robotsRule struct {
+	Allow   bool
+	Length  int
+	Pattern *regexp.Regexp
+}
+
+var (
+	// robotsCache holds the rules of each origin whose robots.txt was
+	// already fetched, guarded by robotsMu since targets are scanned
+	// concurrently
+	robotsCache = make(map[string][]robotsRule)
+	robotsMu    sync.Mutex
+)
+
+// robotsAllowed reports whether the robots.txt of a URL's origin lets
+// robotsAgent fetch it. Following RFC 9309, a robots.txt that doesn't exist
+// allows everything, while one that can't be fetched disallows everything.
+func robotsAllowed(ctx context.Context, c *http.Client, rawURL string) (bool, error) {
+	u, err := neturl.Parse(normalizeURL(rawURL))
+	if err != nil {
+		return false, err
+	}
+	origin := u.Scheme + "://" + u.Host
+
+	robotsMu.Lock()
+	rules, ok := robotsCache[origin]
+	robotsMu.Unlock()
+	if !ok {
+		if rules, err = fetchRobots(ctx, c, origin); err != nil {
+			return false, fmt.Errorf("could not fetch robots.txt: %v", err)
+		}
+		robotsMu.Lock()
+		robotsCache[origin] = rules
+		robotsMu.Unlock()
+	}
+
+	path := u.EscapedPath()
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	// The longest matching rule wins, and Allow wins ties
+	allowed, longest := true, -1
+	for _, rule := range rules {
+		if rule.Pattern.MatchString(path) && (rule.Length > longest || (rule.Length == longest && rule.Allow)) {
+			allowed, longest = rule.Allow, rule.Length
+		}
+	}
+	return allowed, nil
+}
+
+// fetchRobots fetches and parses the robots.txt of an origin
+func fetchRobots(ctx context.Context, c *http.Client, origin string) ([]robotsRule, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return nil, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("server returned %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 500<<10))
+	if err != nil {
+		return nil, err
+	}
+	return parseRobots(body), nil
+}
+
+// parseRobots returns the rules of the robots.txt group for robotsAgent,
+// or of the * group when there is none
+func parseRobots(body []byte) []robotsRule {
+	var agentRules, wildcardRules []robotsRule
+	var agents []string
+	hasAgentGroup := false
+	inRules := false
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines share the group that follows
+			if inRules {
+				agents, inRules = nil, false
+			}
+			agents = append(agents, strings.ToLower(value))
+			hasAgentGroup = hasAgentGroup || strings.EqualFold(value, robotsAgent)
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue
+			}
+			rule := robotsRule{Allow: key == "allow", Length: len(value), Pattern: robotsPattern(value)}
+			for _, agent := range agents {
+				switch agent {
+				case robotsAgent:
+					agentRules = append(agentRules, rule)
+				case "*":
+					wildcardRules = append(wildcardRules, rule)
+				}
+			}
+		}
+	}
+	if hasAgentGroup {
+		return agentRules
+	}
+	return wildcardRules
+}
+
+// robotsPattern compiles a robots.txt path pattern, where * matches any
+// characters and a trailing $ anchors the end of the path
+func robotsPattern(path string) *regexp.Regexp {
+	anchored := strings.HasSuffix(path, "$")
+	path = strings.TrimSuffix(path, "$")
+	parts := strings.Split(path, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	pattern := "^" + strings.Join(parts, ".*")
+	if anchored {
+		pattern += "$"
+	}
+	return regexp.MustCompile(pattern)
+}
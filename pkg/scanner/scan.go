@@ -0,0 +1,183 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// Retries is how many times a failed request is retried, when its
+	// method is idempotent
+	Retries int
+	// RetryBackoff is the delay before the first retry, doubled for each
+	// further one
+	RetryBackoff time.Duration
+	// HostConcurrency caps the targets of the same host scanned at once,
+	// where zero is unlimited
+	HostConcurrency int
+)
+
+// ScanResult is the outcome of scanning a single target
+type ScanResult struct {
+	Result Result
+	Err    error
+	// Skip is why the target was left out of the results, if it was
+	Skip string
+}
+
+// scanTarget fetches a target with the client, retrying failures other than
+// certificate errors of idempotent requests with exponential backoff until
+// ctx is done, checks its headers and scores them. The retries are counted
+// in the result, or in the error of a target that still failed.
+func scanTarget(ctx context.Context, c *http.Client, t Target, score func(*http.Response, []HeaderResult) (int, string)) ScanResult {
+	if RespectRobots {
+		allowed, err := robotsAllowed(ctx, c, t.URL)
+		if err != nil {
+			return ScanResult{Skip: err.Error()}
+		}
+		if !allowed {
+			return ScanResult{Skip: "disallowed by robots.txt"}
+		}
+	}
+	resp, body, err := fetch(ctx, c, t.URL, t.Auth)
+	attempt := 0
+	var certErr *certificateError
+	retry := idempotent(RequestMethod)
+	for ; retry && err != nil && !errors.As(err, &certErr) && ctx.Err() == nil && attempt < Retries; attempt++ {
+		if sleep(ctx, RetryBackoff<<attempt) != nil {
+			return ScanResult{Err: ctx.Err()}
+		}
+		resp, body, err = fetch(ctx, c, t.URL, t.Auth)
+	}
+	if err != nil && attempt > 0 {
+		return ScanResult{Err: fmt.Errorf("%w (after %d retries)", err, attempt)}
+	}
+	if err != nil {
+		return ScanResult{Err: err}
+	}
+	results := check(ctx, c, t, resp, body)
+	result := Result{
+		URL:             t.URL,
+		Headers:         results,
+		StatusCode:      resp.StatusCode,
+		Protocol:        resp.Proto,
+		ContentEncoding: resp.Header.Get("Content-Encoding"),
+		TLS:             tlsInfo(resp),
+		Address:         resp.Request.RemoteAddr,
+		Family:          ipFamily(resp.Request.RemoteAddr),
+		Retries:         attempt,
+		Redirects:       redirectChain(resp),
+	}
+	result.Score, result.Grade = score(resp, results)
+	if !successful(resp.StatusCode) {
+		switch StatusHandling {
+		case "skip":
+			return ScanResult{Skip: fmt.Sprintf("responded with status %d", resp.StatusCode)}
+		case "separate":
+			result.Score, result.Grade = 0, UngradedGrade
+		}
+	}
+	// The policy judges the graded result, so it doesn't change the grade
+	if opa, ok := checkOPA(ctx, result); ok {
+		result.Headers = append(result.Headers, opa)
+	}
+	return ScanResult{Result: result}
+}
+
+// ScanTargets scans the targets with a pool of concurrency workers, with
+// at most HostConcurrency of them scanning the same host. Each target's
+// outcome is delivered on its own channel, so callers can report them in
+// input order while later targets are still being fetched. Once ctx is
+// done the targets not scanned yet fail with its error.
+func ScanTargets(ctx context.Context, targets []Target, concurrency int, score func(*http.Response, []HeaderResult) (int, string)) []chan ScanResult {
+	return scanTargets(ctx, Client, targets, concurrency, score)
+}
+
+// scanTargets is ScanTargets with the given client
+func scanTargets(ctx context.Context, c *http.Client, targets []Target, concurrency int, score func(*http.Response, []HeaderResult) (int, string)) []chan ScanResult {
+	outcomes := make([]chan ScanResult, len(targets))
+	for i := range outcomes {
+		outcomes[i] = make(chan ScanResult, 1)
+	}
+
+	s := newScheduler(targets, HostConcurrency)
+	for w := 0; w < concurrency; w++ {
+		go func() {
+			for {
+				i, ok := s.next()
+				if !ok {
+					return
+				}
+				if err := ctx.Err(); err != nil {
+					outcomes[i] <- ScanResult{Err: err}
+				} else {
+					outcomes[i] <- scanTarget(ctx, c, targets[i], score)
+				}
+				s.done(i)
+			}
+		}()
+	}
+	return outcomes
+}
+
+// scheduler hands out targets in input order, skipping over those whose
+// host already has perHost scans running, so workers move on to other
+// hosts instead of queuing up behind a single origin
+type scheduler struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	hosts   []string
+	pending []int
+	active  map[string]int
+	perHost int
+}
+
+// newScheduler returns a scheduler of the targets, where a perHost of zero
+// is unlimited
+func newScheduler(targets []Target, perHost int) *scheduler {
+	s := &scheduler{active: make(map[string]int), perHost: perHost}
+	s.cond = sync.NewCond(&s.mu)
+	for i, t := range targets {
+		host := ""
+		if u, err := neturl.Parse(normalizeURL(t.URL)); err == nil {
+			host = strings.ToLower(u.Hostname())
+		}
+		s.hosts = append(s.hosts, host)
+		s.pending = append(s.pending, i)
+	}
+	return s
+}
+
+// next returns the first pending target whose host is below its limit,
+// waiting for a running scan to finish when there is none, and reports
+// false once every target was handed out
+func (s *scheduler) next() (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.pending) > 0 {
+		for j, i := range s.pending {
+			if s.perHost > 0 && s.active[s.hosts[i]] >= s.perHost {
+				continue
+			}
+			s.pending = append(s.pending[:j], s.pending[j+1:]...)
+			s.active[s.hosts[i]]++
+			return i, true
+		}
+		s.cond.Wait()
+	}
+	return 0, false
+}
+
+// done records that the scan of a target finished
+func (s *scheduler) done(i int) {
+	s.mu.Lock()
+	s.active[s.hosts[i]]--
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
@@ -0,0 +1,419 @@
+// Package scanner fetches URLs and checks their security headers, the
+// engine behind the gosecurityheaders command. A Scanner made by New
+// fetches, checks and grades URLs, while Fetch and CheckHeaders run the
+// steps separately. The header checks are registered Checks, which Enable
+// and Disable toggle and Register adds to, while settings such as the
+// optional checks are package variables the command sets from its flags.
+// Both are process-global, shared by every Scanner of the process.
+package scanner
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	neturl "net/url"
+	"strings"
+)
+
+var (
+	// Short names accepted by --headers for the common security headers
+	headerAliases = map[string]string{
+		"CSP":   "Content-Security-Policy",
+		"HSTS":  "Strict-Transport-Security",
+		"XFO":   "X-Frame-Options",
+		"XCTO":  "X-Content-Type-Options",
+		"PP":    "Permissions-Policy",
+		"COOP":  "Cross-Origin-Opener-Policy",
+		"COEP":  "Cross-Origin-Embedder-Policy",
+		"CORP":  "Cross-Origin-Resource-Policy",
+		"XPCDP": "X-Permitted-Cross-Domain-Policies",
+	}
+
+	// Client sends the requests, and must be set before fetching
+	Client *http.Client
+)
+
+// normalizeURL defaults URLs without a scheme to http
+func normalizeURL(url string) string {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		url = "http://" + url
+	}
+	return url
+}
+
+// DefaultUserAgent identifies the scanner while resembling a browser, since
+// some WAFs block or serve different headers to Go's default User-Agent
+const DefaultUserAgent = "Mozilla/5.0 (compatible; gosecurityheaders; +https://github.com/an00byss/gosecurityheaders)"
+
+// UserAgent is the User-Agent sent with every request
+var UserAgent = DefaultUserAgent
+
+// maxBodySize limits how much of each response body is read for the checks
+// that inspect the content
+const maxBodySize = 1 << 20
+
+// RequestMethod is the method of the requests, such as GET, HEAD to skip
+// downloading bodies, or POST for endpoints that only answer to it
+var RequestMethod = http.MethodGet
+
+// headRejected are the statuses of servers that don't support HEAD, whose
+// requests are sent again with GET
+var headRejected = map[int]bool{
+	http.StatusMethodNotAllowed: true,
+	http.StatusNotImplemented:   true,
+}
+
+// Fetch fetches a URL with the --method, following redirects, and
+// returns the final response with its body closed along with the start of
+// the body, which is empty for HEAD requests. The request is abandoned when
+// ctx is done.
+func Fetch(ctx context.Context, url string, auth Credentials) (*http.Response, []byte, error) {
+	return fetch(ctx, Client, url, auth)
+}
+
+// fetch is Fetch with the given client
+func fetch(ctx context.Context, c *http.Client, url string, auth Credentials) (*http.Response, []byte, error) {
+	resp, body, err := fetchWith(ctx, c, RequestMethod, url, auth)
+	if err == nil && RequestMethod == http.MethodHead && headRejected[resp.StatusCode] {
+		return fetchWith(ctx, c, http.MethodGet, url, auth)
+	}
+	return resp, body, err
+}
+
+// fetchWith fetches a URL with the given client and method. The address
+// that served the final response is recorded in the RemoteAddr of its
+// request, which clients otherwise leave empty.
+func fetchWith(ctx context.Context, c *http.Client, method, url string, auth Credentials) (*http.Response, []byte, error) {
+	req, err := newRequest(method, normalizeURL(url))
+	if err != nil {
+		return nil, nil, err
+	}
+	var remoteAddr string
+	req = req.WithContext(httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			remoteAddr = info.Conn.RemoteAddr().String()
+		},
+	}))
+	req.Header.Set("User-Agent", UserAgent)
+	if AcceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", AcceptEncoding)
+	}
+	if CORSOrigin != "" {
+		req.Header.Set("Origin", CORSOrigin)
+	}
+	if !auth.NoDefaults {
+		setRequestHeaders(req)
+	}
+	setAuth(req, auth)
+	if !auth.NoDefaults {
+		setRequestCookies(req)
+	}
+
+	jarClient := *c
+	jarClient.Jar = newCookieJar(!auth.NoDefaults)
+	resp, err := jarClient.Do(req)
+	if err != nil {
+		return nil, nil, classifyCertificateError(err)
+	}
+	defer resp.Body.Close()
+	resp.Request.RemoteAddr = remoteAddr
+
+	// A body that can't be decoded or read only skips the checks of the
+	// body, the headers are still checked
+	reader, err := decodeBody(resp)
+	if err != nil || reader == nil {
+		return resp, nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(reader, maxBodySize))
+	if err != nil {
+		return resp, nil, nil
+	}
+	return resp, body, nil
+}
+
+// HeaderResult holds the check result for a single header
+type HeaderResult struct {
+	Name     string   `json:"name" yaml:"name"`
+	Present  bool     `json:"present" yaml:"present"`
+	Status   Status   `json:"status" yaml:"status"`
+	Value    string   `json:"value,omitempty" yaml:"value,omitempty"`
+	Values   []string `json:"values,omitempty" yaml:"values,omitempty"`
+	Severity Severity `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Issues   []string `json:"issues,omitempty" yaml:"issues,omitempty"`
+	Warnings []string `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	// Category groups results other than headers, e.g. categoryTransport
+	// for the TLS checks
+	Category string `json:"category,omitempty" yaml:"category,omitempty"`
+}
+
+// setValues records the values of a header. Value holds the combined
+// comma-separated value, and Values each value when the header was sent
+// more than once.
+func (r *HeaderResult) setValues(values []string) {
+	r.Value = strings.Join(values, ", ")
+	if len(values) > 1 {
+		r.Values = values
+	}
+}
+
+// Result holds the check results for a single URL
+type Result struct {
+	URL     string         `json:"url" yaml:"url"`
+	Grade   string         `json:"grade" yaml:"grade"`
+	Score   int            `json:"score" yaml:"score"`
+	Headers []HeaderResult `json:"headers" yaml:"headers"`
+	// StatusCode is the HTTP status code of the final response
+	StatusCode int `json:"status_code,omitempty" yaml:"status_code,omitempty"`
+	// Protocol is the protocol of the final response, e.g. HTTP/2.0
+	Protocol string `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+	// ContentEncoding is the Content-Encoding of the final response
+	ContentEncoding string `json:"content_encoding,omitempty" yaml:"content_encoding,omitempty"`
+	// TLS describes the TLS connection of the final response
+	TLS *TLSInfo `json:"tls,omitempty" yaml:"tls,omitempty"`
+	// Address is the IP address and port that served the final response,
+	// and Family whether it is IPv4 or IPv6
+	Address string `json:"address,omitempty" yaml:"address,omitempty"`
+	Family  string `json:"family,omitempty" yaml:"family,omitempty"`
+	// Retries counts the failed attempts before the URL could be fetched
+	Retries int `json:"retries,omitempty" yaml:"retries,omitempty"`
+	// Redirects holds the redirect chain that led to the final response
+	Redirects []Hop `json:"redirects,omitempty" yaml:"redirects,omitempty"`
+	// Waived holds the findings suppressed by the --ignore file
+	Waived []WaivedFinding `json:"waived,omitempty" yaml:"waived,omitempty"`
+}
+
+// SchemaVersion is the version of the results in the JSON, YAML and NDJSON
+// output. New checks and optional fields keep it, so parsers should ignore
+// the fields they don't know, while removing or changing fields, statuses
+// or severities increments it.
+const SchemaVersion = "1"
+
+// hasHeader reports whether the header was sent, even with an empty value
+func hasHeader(headers http.Header, name string) bool {
+	_, present := headers[http.CanonicalHeaderKey(name)]
+	return present
+}
+
+// CheckHeaders checks which headers are present, misconfigured or missing,
+// running the optional checks enabled for the target. The checks that send
+// requests of their own stop when ctx is done.
+func CheckHeaders(ctx context.Context, t Target, resp *http.Response, body []byte) []HeaderResult {
+	return check(ctx, Client, t, resp, body)
+}
+
+// check is CheckHeaders with the given client
+func check(ctx context.Context, c *http.Client, t Target, resp *http.Response, body []byte) []HeaderResult {
+	headers := resp.Header
+	results := checkRegistered(ctx, resp, body)
+
+	if contentType, ok := checkContentType(headers, body); ok {
+		results = append(results, contentType)
+	}
+	if cookies, ok := checkCookies(headers); ok {
+		results = append(results, cookies)
+	}
+	if cors, ok := checkCORS(headers); ok {
+		results = append(results, cors)
+	}
+	if isSensitive(t) {
+		results = append(results, checkCacheControl(headers))
+	}
+	if isLogout(t) {
+		results = append(results, checkClearSiteData(headers))
+	}
+	if ReportingChecks {
+		results = checkReporting(headers, results)
+	}
+	if (PreloadChecks || NonceChecks) && resp.Request != nil {
+		for i := range results {
+			switch {
+			case results[i].Name == "Strict-Transport-Security" && results[i].Present && PreloadChecks:
+				checkPreload(ctx, c, resp.Request.URL, &results[i])
+			case results[i].Name == "Content-Security-Policy" && results[i].Present && NonceChecks:
+				checkNonces(ctx, c, resp.Request.URL, t.Auth, &results[i])
+			}
+		}
+	}
+	for i := range results {
+		if results[i].Name == "Strict-Transport-Security" && results[i].Present {
+			checkHSTSLifetime(ctx, resp, &results[i])
+		}
+	}
+	if resp.Request != nil && (SRIChecks || resp.Request.URL.Scheme == "https") {
+		resources := parseSubresources(body, resp.Request.URL)
+		if SRIChecks {
+			if sri, ok := checkSRI(resources); ok {
+				results = append(results, sri)
+			}
+		}
+		if resp.Request.URL.Scheme == "https" {
+			if mixed, ok := checkMixedContent(headers, resources); ok {
+				results = append(results, mixed)
+			}
+		}
+	}
+	if redirects, ok := checkRedirects(resp, results); ok {
+		results = append(results, redirects)
+	}
+	if protocol, ok := checkProtocol(resp); ok {
+		results = append(results, protocol)
+	}
+	if certificate, ok := checkCertificate(resp); ok {
+		results = append(results, certificate)
+	}
+	if OCSPChecks {
+		if stapling, ok := checkOCSP(resp); ok {
+			results = append(results, stapling)
+		}
+	}
+	if CTChecks {
+		if transparency, ok := checkCT(resp); ok {
+			results = append(results, transparency)
+		}
+	}
+	if CAAChecks && resp.Request != nil {
+		if caa, ok := checkCAA(ctx, resp.Request.URL.Hostname()); ok {
+			results = append(results, caa)
+		}
+	}
+	if status, ok := checkStatus(resp); ok {
+		results = append(results, status)
+	}
+	if HTTP3Checks && resp.Request != nil {
+		if h3, ok := checkHTTP3(ctx, resp.Request.URL, t.Auth, headers); ok {
+			results = append(results, h3)
+		}
+	}
+	if TLSProbes && resp.Request != nil {
+		if legacy, ok := checkLegacyTLS(ctx, resp.Request.URL); ok {
+			results = append(results, legacy)
+		}
+	}
+
+	// Deprecated and information disclosure headers are only reported when
+	// they are sent
+	results = append(results, flagHeaders(headers, deprecatedHeaders, StatusDeprecated)...)
+	for _, result := range flagHeaders(headers, disclosureHeaders, StatusDisclosure) {
+		if disclosesVersion(result.Value) && !strings.Contains(result.Issues[0], "version") {
+			result.Issues[0] += " including its version"
+		}
+		results = append(results, result)
+	}
+
+	results = checkPolicy(t, resp, results)
+	for i := range results {
+		results[i].Severity = SeverityFor(results[i])
+	}
+	return results
+}
+
+// flagHeaders returns a result with the given status for each of the
+// flagged headers that was sent
+func flagHeaders(headers http.Header, flagged []flaggedHeader, status Status) []HeaderResult {
+	var results []HeaderResult
+	for _, header := range flagged {
+		if hasHeader(headers, header.Name) {
+			result := HeaderResult{
+				Name:    header.Name,
+				Present: true,
+				Status:  status,
+				Issues:  []string{header.Reason},
+			}
+			result.setValues(headers.Values(header.Name))
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// FindHeader returns the result for the named header
+func FindHeader(results []HeaderResult, name string) (HeaderResult, bool) {
+	for _, result := range results {
+		if result.Name == name {
+			return result, true
+		}
+	}
+	return HeaderResult{}, false
+}
+
+// ParseHeaderList parses a comma-separated list of header names or their
+// aliases into canonical header names
+func ParseHeaderList(value string) []string {
+	var headers []string
+	seen := make(map[string]bool)
+	for _, name := range SplitList(value) {
+		if header, ok := headerAliases[strings.ToUpper(name)]; ok {
+			name = header
+		}
+		if name = http.CanonicalHeaderKey(name); !seen[name] {
+			seen[name] = true
+			headers = append(headers, name)
+		}
+	}
+	return headers
+}
+
+// HeadersWithStatus returns the names of the headers with the given status
+func HeadersWithStatus(results []HeaderResult, status Status) []string {
+	var names []string
+	for _, result := range results {
+		if result.Status == status {
+			names = append(names, result.Name)
+		}
+	}
+	return names
+}
+
+// MissingHeaders returns the names of the headers that are missing
+func MissingHeaders(results []HeaderResult) []string {
+	return HeadersWithStatus(results, StatusMissing)
+}
+
+// MisconfiguredHeaders returns the names of the headers that are present
+// but misconfigured
+func MisconfiguredHeaders(results []HeaderResult) []string {
+	return HeadersWithStatus(results, StatusMisconfigured)
+}
+
+// Target is a URL to scan along with the tags that enable optional checks
+// and the credentials to authenticate with
+type Target struct {
+	URL  string
+	Tags []string
+	Auth Credentials
+}
+
+// hasTag reports whether the target has the given tag
+func (t Target) hasTag(tag string) bool {
+	for _, tt := range t.Tags {
+		if strings.EqualFold(tt, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether the target has the tag or its path contains one
+// of the fragments, ignoring case
+func (t Target) matches(tag string, fragments []string) bool {
+	if t.hasTag(tag) {
+		return true
+	}
+	path := strings.ToLower(urlPath(t.URL))
+	for _, fragment := range fragments {
+		if strings.Contains(path, strings.ToLower(fragment)) {
+			return true
+		}
+	}
+	return false
+}
+
+// urlPath returns the path of a URL, which may omit its scheme
+func urlPath(rawURL string) string {
+	u, err := neturl.Parse(normalizeURL(rawURL))
+	if err != nil || u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
@@ -0,0 +1,55 @@
+package scanner
+
+import (
+	"net/http"
+)
+
+// securityHeadersGraded are the headers securityheaders.com grades on
+var securityHeadersGraded = []string{
+	"Content-Security-Policy",
+	"Strict-Transport-Security",
+	"X-Frame-Options",
+	"X-Content-Type-Options",
+	"Referrer-Policy",
+	"Permissions-Policy",
+}
+
+// securityHeadersGrades maps the number of missing headers to the grade
+// securityheaders.com gives, with A+ reserved for sites that send every
+// header without warnings
+var securityHeadersGrades = []string{"A", "B", "C", "D", "E"}
+
+// securityHeadersScore approximates the securityheaders.com grade, which
+// drops one letter for each graded header that is missing. Report-only and
+// misconfigured headers count as missing, and Strict-Transport-Security is
+// only graded over HTTPS since browsers ignore it over HTTP. Headers whose
+// checks are turned off with --headers or --disable are not graded either.
+// The score is the percentage of graded headers that are present.
+func securityHeadersScore(resp *http.Response, results []HeaderResult) (int, string) {
+	https := resp.Request != nil && resp.Request.URL.Scheme == "https"
+	graded, missing, warnings := 0, 0, 0
+	for _, name := range securityHeadersGraded {
+		if name == "Strict-Transport-Security" && !https || !IsRequiredHeader(name) {
+			continue
+		}
+		graded++
+		header, _ := FindHeader(results, name)
+		if header.Status != StatusPresent {
+			missing++
+		}
+		warnings += len(header.Warnings)
+	}
+
+	score := 100
+	if graded > 0 {
+		score = (graded - missing) * 100 / graded
+	}
+	switch {
+	case missing == 0 && warnings == 0:
+		return score, "A+"
+	case missing < len(securityHeadersGrades):
+		return score, securityHeadersGrades[missing]
+	default:
+		return score, "F"
+	}
+}
@@ -0,0 +1,145 @@
+package scanner
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severity ranks how much a header result weakens the site's security
+type Severity string
+
+const (
+	SeverityCritical Severity = "Critical"
+	SeverityHigh     Severity = "High"
+	SeverityMedium   Severity = "Medium"
+	SeverityInfo     Severity = "Info"
+)
+
+// Severities lists the severities from most to least severe
+var Severities = []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityInfo}
+
+// headerSeverities maps the results of the checks outside the registry to
+// the severity of them failing, and holds the overrides of --severities.
+// Other results have the severity of their registered check, or Info.
+var headerSeverities = map[string]Severity{
+	"Set-Cookie":                  SeverityHigh,
+	"Access-Control-Allow-Origin": SeverityHigh,
+	"Cache-Control":               SeverityHigh,
+	"Clear-Site-Data":             SeverityMedium,
+	"Content-Type":                SeverityMedium,
+	"Subresource-Integrity":       SeverityHigh,
+	"Mixed-Content":               SeverityHigh,
+	"Redirect-Chain":              SeverityMedium,
+	"HTTP/3":                      SeverityMedium,
+	"TLS-Certificate":             SeverityHigh,
+	"TLS-Protocol":                SeverityHigh,
+	"OCSP-Stapling":               SeverityMedium,
+	"Legacy-TLS":                  SeverityHigh,
+	"Certificate-Transparency":    SeverityMedium,
+	"CAA":                         SeverityMedium,
+	"Public-Key-Pins":             SeverityMedium,
+	"OPA-Policy":                  SeverityHigh,
+}
+
+// parseSeverity parses a severity name, ignoring case
+func parseSeverity(name string) (Severity, error) {
+	for _, severity := range Severities {
+		if strings.EqualFold(name, string(severity)) {
+			return severity, nil
+		}
+	}
+	return "", fmt.Errorf("unknown severity %q", name)
+}
+
+// LoadSeverities overrides the header severities with the mapping of
+// header names to severities in a YAML or JSON file
+func LoadSeverities(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	var overrides map[string]string
+	if err := yaml.Unmarshal(data, &overrides); err != nil {
+		return err
+	}
+	for name, value := range overrides {
+		severity, err := parseSeverity(value)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		headerSeverities[http.CanonicalHeaderKey(name)] = severity
+	}
+	return nil
+}
+
+// SeverityFor classifies a header result. Present headers with warnings
+// are Info, report-only headers rank one level below the header's own
+// severity, and clean results have no severity.
+func SeverityFor(result HeaderResult) Severity {
+	severity, ok := headerSeverities[result.Name]
+	if !ok {
+		severity = SeverityInfo
+		if check := registered(result.Name); check != nil {
+			severity = check.Severity()
+		}
+	}
+
+	switch result.Status {
+	case StatusPresent:
+		if len(result.Issues) > 0 || len(result.Warnings) > 0 {
+			return SeverityInfo
+		}
+		return ""
+	case StatusReportOnly:
+		for i, s := range Severities[:len(Severities)-1] {
+			if s == severity {
+				return Severities[i+1]
+			}
+		}
+	}
+	return severity
+}
+
+// HighestSeverity returns the most severe classification among the header
+// results, or an empty severity when none has findings
+func HighestSeverity(results []HeaderResult) Severity {
+	for _, severity := range Severities {
+		for _, result := range results {
+			if result.Severity == severity {
+				return severity
+			}
+		}
+	}
+	return ""
+}
+
+// ParseFailOn parses a --fail-on threshold, a severity name or "any" for
+// findings of every severity
+func ParseFailOn(value string) (Severity, error) {
+	if strings.EqualFold(value, "any") {
+		return SeverityInfo, nil
+	}
+	return parseSeverity(value)
+}
+
+// MeetsSeverity reports whether any header result is at least as severe
+// as the threshold
+func MeetsSeverity(results []HeaderResult, threshold Severity) bool {
+	highest := HighestSeverity(results)
+	if highest == "" {
+		return false
+	}
+	for _, severity := range Severities {
+		if severity == highest {
+			return true
+		}
+		if severity == threshold {
+			return false
+		}
+	}
+	return false
+}
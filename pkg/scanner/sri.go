@@ -0,0 +1,62 @@
+package scanner
+
+import (
+	"strings"
+)
+
+// SRIChecks enables the Subresource Integrity audit of HTML pages
+var SRIChecks bool
+
+// sriAlgorithms are the hash algorithms browsers accept for integrity
+var sriAlgorithms = []string{"sha256-", "sha384-", "sha512-"}
+
+// checkSRI reports scripts and stylesheets loaded from third-party hosts
+// without an integrity attribute, which lets a compromised host change the
+// code the page runs. It reports false when the page loads none.
+func checkSRI(resources []subresource) (HeaderResult, bool) {
+	var f findings
+	thirdParty := 0
+	for _, resource := range resources {
+		if !resource.SRI || !resource.ThirdParty {
+			continue
+		}
+		thirdParty++
+
+		switch {
+		case resource.Integrity == "":
+			f.issue("<%s> loads %s without integrity", resource.Tag, resource.URL)
+		case !validIntegrity(resource.Integrity):
+			f.issue("<%s> loading %s has no valid sha256, sha384 or sha512 integrity hash", resource.Tag, resource.URL)
+		case !resource.CrossOrigin:
+			f.issue("<%s> loading %s has integrity but no crossorigin attribute, so browsers block it", resource.Tag, resource.URL)
+		}
+	}
+	if thirdParty == 0 {
+		return HeaderResult{}, false
+	}
+
+	result := HeaderResult{
+		Name:     "Subresource-Integrity",
+		Present:  true,
+		Status:   StatusPresent,
+		Issues:   f.Issues,
+		Warnings: f.Warnings,
+	}
+	if len(f.Issues) > 0 {
+		result.Status = StatusMisconfigured
+	}
+	return result, true
+}
+
+// validIntegrity reports whether an integrity attribute lists at least one
+// hash with an algorithm browsers support
+func validIntegrity(integrity string) bool {
+	for _, hash := range strings.Fields(integrity) {
+		for _, algorithm := range sriAlgorithms {
+			if strings.HasPrefix(strings.ToLower(hash), algorithm) && len(hash) > len(algorithm) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package scanner
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// StatusHandlings are the ways --non-2xx handles responses whose status
+// is not 2xx, since the headers of a CDN or WAF error page say nothing
+// about the application behind it:
+//
+//   - report scores them like any other response
+//   - warn adds a Status-Code result warning about the status
+//   - skip leaves the URL out of the results
+//   - separate reports the URL with an N/A grade that --fail-on ignores
+var StatusHandlings = []string{"report", "warn", "skip", "separate"}
+
+// StatusHandling is the --non-2xx handling in use
+var StatusHandling = "report"
+
+// UngradedGrade is the grade of responses reported separately
+const UngradedGrade = "N/A"
+
+// successful reports whether a status code is 2xx
+func successful(code int) bool {
+	return code >= 200 && code < 300
+}
+
+// checkStatus returns the Status-Code result of a non-2xx response when
+// --non-2xx=warn, reporting false otherwise
+func checkStatus(resp *http.Response) (HeaderResult, bool) {
+	if StatusHandling != "warn" || successful(resp.StatusCode) {
+		return HeaderResult{}, false
+	}
+	return HeaderResult{
+		Name:    "Status-Code",
+		Present: true,
+		Status:  StatusPresent,
+		Value:   resp.Status,
+		Warnings: []string{fmt.Sprintf("the response status is %s, so the headers may come from an error page rather than the application",
+			resp.Status)},
+	}, true
+}
+
+// IsStatusHandling reports whether --non-2xx supports the handling
+func IsStatusHandling(handling string) bool {
+	for _, h := range StatusHandlings {
+		if h == handling {
+			return true
+		}
+	}
+	return false
+}
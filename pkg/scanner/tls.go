@@ -0,0 +1,289 @@
+package scanner
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// LoadCACert returns the system roots along with the certificates of a PEM
+// bundle, so sites signed by an internal CA can be verified instead of
+// skipping verification altogether
+func LoadCACert(filePath string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("no PEM certificates found")
+	}
+	return pool, nil
+}
+
+// LoadClientCert loads the certificate presented to servers requiring
+// mutual TLS. Without a key file the key is read from the certificate file.
+func LoadClientCert(certFile, keyFile string) (tls.Certificate, error) {
+	if keyFile == "" {
+		keyFile = certFile
+	}
+	return tls.LoadX509KeyPair(certFile, keyFile)
+}
+
+const (
+	// categoryTransport is the category of the results of the TLS checks
+	categoryTransport = "transport"
+	// categoryDNS is the category of the results of the DNS record checks
+	categoryDNS = "dns"
+)
+
+// CertWarnDays is how many days before its certificate expires a site is
+// warned about
+var CertWarnDays int
+
+// ScanCipherSuites returns every cipher suite Go implements, including the
+// insecure ones it doesn't offer by default, so that a server preferring a
+// weak suite is reported instead of failing the handshake
+func ScanCipherSuites() []uint16 {
+	var ids []uint16
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		ids = append(ids, suite.ID)
+	}
+	return ids
+}
+
+// TLSInfo describes the TLS connection that served the final response
+type TLSInfo struct {
+	// Version is the negotiated protocol version, e.g. "TLS 1.3", and
+	// CipherSuite the negotiated cipher suite
+	Version     string `json:"version" yaml:"version"`
+	CipherSuite string `json:"cipher_suite" yaml:"cipher_suite"`
+	// Expires is when the leaf certificate expires, and DaysLeft how many
+	// days remain until then
+	Expires  time.Time `json:"expires" yaml:"expires"`
+	DaysLeft int       `json:"days_left" yaml:"days_left"`
+	// OCSPStapled reports whether the server stapled an OCSP response
+	OCSPStapled bool `json:"ocsp_stapled" yaml:"ocsp_stapled"`
+	// Certificate describes the certificate chain with --cert-details
+	Certificate *CertificateInfo `json:"certificate,omitempty" yaml:"certificate,omitempty"`
+}
+
+// tlsInfo returns the TLS details of a response, or nil when it wasn't
+// served over TLS
+func tlsInfo(resp *http.Response) *TLSInfo {
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	leaf := resp.TLS.PeerCertificates[0]
+	info := &TLSInfo{
+		Version:     tls.VersionName(resp.TLS.Version),
+		CipherSuite: tls.CipherSuiteName(resp.TLS.CipherSuite),
+		Expires:     leaf.NotAfter,
+		DaysLeft:    int(math.Floor(time.Until(leaf.NotAfter).Hours() / 24)),
+		OCSPStapled: len(resp.TLS.OCSPResponse) > 0,
+	}
+	if CertDetails {
+		info.Certificate = certificateInfo(resp.TLS, resp.Request.URL.Hostname())
+	}
+	return info
+}
+
+// String summarizes the TLS details, e.g. "TLS 1.3 with
+// TLS_AES_128_GCM_SHA256, certificate expires 2026-01-02 (30 days)"
+func (info *TLSInfo) String() string {
+	s := fmt.Sprintf("%s with %s, certificate expires %s (%d days)", info.Version, info.CipherSuite, info.Expires.Format("2006-01-02"), info.DaysLeft)
+	if info.OCSPStapled {
+		s += ", OCSP stapled"
+	}
+	return s
+}
+
+// checkProtocol reports a connection negotiated over TLS 1.0 or 1.1, which
+// RFC 8996 deprecates, or with a weak cipher suite. It reports false when
+// the response wasn't served over TLS.
+func checkProtocol(resp *http.Response) (HeaderResult, bool) {
+	if resp.TLS == nil {
+		return HeaderResult{}, false
+	}
+	version, suite := resp.TLS.Version, tls.CipherSuiteName(resp.TLS.CipherSuite)
+
+	var f findings
+	if version < tls.VersionTLS12 {
+		f.issue("%s is deprecated, TLS 1.2 or later should be required", tls.VersionName(version))
+	}
+	for _, insecure := range tls.InsecureCipherSuites() {
+		if insecure.ID == resp.TLS.CipherSuite {
+			f.issue("%s is a known-weak cipher suite", suite)
+		}
+	}
+	if len(f.Issues) == 0 {
+		switch {
+		case strings.HasPrefix(suite, "TLS_RSA_"):
+			f.warn("%s uses RSA key exchange, which lacks forward secrecy", suite)
+		case strings.Contains(suite, "_CBC_"):
+			f.warn("%s uses CBC mode, prefer an AEAD cipher suite", suite)
+		}
+	}
+
+	result := HeaderResult{
+		Name:     "TLS-Protocol",
+		Category: categoryTransport,
+		Present:  true,
+		Status:   StatusPresent,
+		Value:    tls.VersionName(version) + " " + suite,
+		Issues:   f.Issues,
+		Warnings: f.Warnings,
+	}
+	if len(f.Issues) > 0 {
+		result.Status = StatusMisconfigured
+	}
+	return result, true
+}
+
+// checkCertificate reports a leaf certificate that expired or expires
+// within CertWarnDays, or with --cert-details whose chain doesn't verify.
+// It reports false when the response wasn't served over TLS.
+func checkCertificate(resp *http.Response) (HeaderResult, bool) {
+	info := tlsInfo(resp)
+	if info == nil {
+		return HeaderResult{}, false
+	}
+	result := HeaderResult{
+		Name:     "TLS-Certificate",
+		Category: categoryTransport,
+		Present:  true,
+		Status:   StatusPresent,
+		Value:    "expires " + info.Expires.Format(time.RFC3339),
+	}
+	switch {
+	case info.DaysLeft < 0:
+		result.Status = StatusMisconfigured
+		result.Issues = append(result.Issues, fmt.Sprintf("the certificate expired %d days ago", -info.DaysLeft))
+	case info.Certificate != nil && !info.Certificate.Valid:
+		result.Status = StatusMisconfigured
+		result.Issues = append(result.Issues, "the certificate does not verify: "+info.Certificate.Problem)
+	case info.DaysLeft < CertWarnDays:
+		result.Warnings = append(result.Warnings, fmt.Sprintf("the certificate expires in %d days", info.DaysLeft))
+	}
+	return result, true
+}
+
+var (
+	// CertDetails enables reporting the subject, issuer, SANs and chain
+	// validity of certificates
+	CertDetails bool
+
+	// CertRoots are the roots certificate chains are verified against, where
+	// nil means the system roots
+	CertRoots *x509.CertPool
+)
+
+// CertificateInfo describes the certificate chain a server presented
+type CertificateInfo struct {
+	Subject string   `json:"subject" yaml:"subject"`
+	Issuer  string   `json:"issuer" yaml:"issuer"`
+	SANs    []string `json:"sans,omitempty" yaml:"sans,omitempty"`
+	// Chain lists the subjects of the certificates sent after the leaf
+	Chain []string `json:"chain,omitempty" yaml:"chain,omitempty"`
+	// Valid reports whether the chain verifies for the host, and Problem
+	// why it doesn't
+	Valid   bool   `json:"valid" yaml:"valid"`
+	Problem string `json:"problem,omitempty" yaml:"problem,omitempty"`
+}
+
+// certificateInfo describes the chain of a TLS connection to a host,
+// verifying it even when --skip-ssl let the connection through
+func certificateInfo(state *tls.ConnectionState, host string) *CertificateInfo {
+	leaf := state.PeerCertificates[0]
+	info := &CertificateInfo{
+		Subject: leaf.Subject.String(),
+		Issuer:  leaf.Issuer.String(),
+		SANs:    leaf.DNSNames,
+	}
+	for _, ip := range leaf.IPAddresses {
+		info.SANs = append(info.SANs, ip.String())
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		info.Chain = append(info.Chain, cert.Subject.String())
+		intermediates.AddCert(cert)
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{DNSName: host, Roots: CertRoots, Intermediates: intermediates})
+	info.Valid = err == nil
+	if err != nil {
+		if info.Problem = certificateProblem(err); info.Problem == "" {
+			info.Problem = err.Error()
+		}
+	}
+	return info
+}
+
+// String summarizes the certificate, e.g. "CN=example.com issued by CN=R3,
+// SANs example.com, www.example.com"
+func (info *CertificateInfo) String() string {
+	s := info.Subject + " issued by " + info.Issuer
+	if len(info.SANs) > 0 {
+		s += ", SANs " + strings.Join(info.SANs, ", ")
+	}
+	if info.Problem != "" {
+		s += " (" + info.Problem + ")"
+	}
+	return s
+}
+
+// certificateError is a failed request whose certificate didn't verify,
+// which isn't retried since it won't resolve itself
+type certificateError struct {
+	problem string
+	err     error
+}
+
+func (e *certificateError) Error() string {
+	return e.problem + ": " + e.err.Error()
+}
+
+func (e *certificateError) Unwrap() error {
+	return e.err
+}
+
+// classifyCertificateError wraps the error of a request whose certificate
+// is self-signed, expired or for another host, so that it says so instead
+// of reporting a generic TLS failure
+func classifyCertificateError(err error) error {
+	if problem := certificateProblem(err); problem != "" {
+		return &certificateError{problem: problem, err: err}
+	}
+	return err
+}
+
+// certificateProblem names the verification error of a certificate, or
+// returns "" when err isn't one
+func certificateProblem(err error) string {
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	var authorityErr x509.UnknownAuthorityError
+	switch {
+	case errors.As(err, &hostnameErr):
+		return "hostname mismatch"
+	case errors.As(err, &invalidErr) && invalidErr.Reason == x509.Expired:
+		return "expired certificate"
+	case errors.As(err, &authorityErr):
+		if cert := authorityErr.Cert; cert != nil && bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil {
+			return "self-signed certificate"
+		}
+		return "untrusted issuer"
+	case errors.As(err, &invalidErr):
+		return "invalid certificate"
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	neturl "net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// TLSProbes enables the handshakes probing HTTPS servers for legacy
+	// protocols and RSA key exchange
+	TLSProbes bool
+
+	// LegacyProber runs the probe handshakes
+	LegacyProber *TLSProber
+)
+
+// TLSProber performs TLS handshakes offering only a given protocol version
+// and set of cipher suites, to learn whether a server accepts them when a
+// client insists
+type TLSProber struct {
+	config  *tls.Config
+	dial    func(ctx context.Context, network, address string) (net.Conn, error)
+	timeout time.Duration
+	limiter *RateLimiter
+}
+
+// NewTLSProber returns a prober dialing like the scan requests and sharing
+// their rate limits when limiter is not nil. Certificates aren't verified
+// since the probes only test what the server negotiates.
+func NewTLSProber(tlsConfig *tls.Config, dial func(ctx context.Context, network, address string) (net.Conn, error), timeout time.Duration, limiter *RateLimiter) *TLSProber {
+	config := tlsConfig.Clone()
+	config.InsecureSkipVerify = true
+	return &TLSProber{config: config, dial: dial, timeout: timeout, limiter: limiter}
+}
+
+// legacyProbe is a handshake whose success is a finding
+type legacyProbe struct {
+	name     string
+	version  uint16
+	suites   []uint16
+	accepted string
+}
+
+// legacyProbes are the handshakes the prober tries, offering TLS 1.0 and
+// 1.1 with every cipher suite and TLS 1.2 with only RSA key exchange suites
+var legacyProbes = []legacyProbe{
+	{name: "TLS 1.0", version: tls.VersionTLS10, suites: ScanCipherSuites(), accepted: "accepts TLS 1.0, which RFC 8996 deprecates"},
+	{name: "TLS 1.1", version: tls.VersionTLS11, suites: ScanCipherSuites(), accepted: "accepts TLS 1.1, which RFC 8996 deprecates"},
+	{name: "RSA key exchange", version: tls.VersionTLS12, suites: rsaKeyExchangeSuites(), accepted: "accepts RSA key exchange, which lacks forward secrecy"},
+}
+
+// rsaKeyExchangeSuites returns the cipher suites using RSA key exchange
+func rsaKeyExchangeSuites() []uint16 {
+	var ids []uint16
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		if strings.HasPrefix(suite.Name, "TLS_RSA_") {
+			ids = append(ids, suite.ID)
+		}
+	}
+	return ids
+}
+
+// handshake connects to address and reports whether the server completes a
+// handshake offering only the probe's version and suites. Errors are
+// returned when the server can't be reached at all.
+func (p *TLSProber) handshake(ctx context.Context, address, serverName string, probe legacyProbe) (bool, error) {
+	if p.limiter != nil {
+		if err := p.limiter.wait(ctx, serverName); err != nil {
+			return false, err
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	conn, err := p.dial(ctx, "tcp", address)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	config := p.config.Clone()
+	config.ServerName = serverName
+	config.MinVersion, config.MaxVersion = probe.version, probe.version
+	config.CipherSuites = probe.suites
+	config.NextProtos = nil
+	tlsConn := tls.Client(conn, config)
+	return tlsConn.HandshakeContext(ctx) == nil, nil
+}
+
+// checkLegacyTLS probes an HTTPS URL's server with separate handshakes for
+// TLS 1.0, TLS 1.1 and RSA key exchange, which a server may still accept
+// even though it negotiates something stronger with modern clients. It
+// reports false for HTTP URLs.
+func checkLegacyTLS(ctx context.Context, u *neturl.URL) (HeaderResult, bool) {
+	if u.Scheme != "https" {
+		return HeaderResult{}, false
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+	address := net.JoinHostPort(u.Hostname(), port)
+
+	var f findings
+	var accepted []string
+	for _, probe := range legacyProbes {
+		ok, err := LegacyProber.handshake(ctx, address, u.Hostname(), probe)
+		switch {
+		case err != nil:
+			f.warn("could not probe %s: %v", probe.name, err)
+		case ok:
+			accepted = append(accepted, probe.name)
+			f.issue("%s", probe.accepted)
+		}
+	}
+
+	result := HeaderResult{
+		Name:     "Legacy-TLS",
+		Category: categoryTransport,
+		Present:  true,
+		Status:   StatusPresent,
+		Value:    "rejects TLS 1.0, TLS 1.1 and RSA key exchange",
+		Issues:   f.Issues,
+		Warnings: f.Warnings,
+	}
+	if len(accepted) > 0 {
+		result.Status = StatusMisconfigured
+		result.Value = "accepts " + strings.Join(accepted, ", ")
+	}
+	return result, true
+}
@@ -0,0 +1,256 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status is the outcome of checking a single header
+type Status string
+
+const (
+	StatusPresent       Status = "Present"
+	StatusMisconfigured Status = "Misconfigured"
+	StatusReportOnly    Status = "Report-Only"
+	StatusDeprecated    Status = "Deprecated"
+	StatusDisclosure    Status = "Disclosure"
+	StatusMissing       Status = "Missing"
+)
+
+// Statuses lists the statuses of the header results
+var Statuses = []Status{StatusPresent, StatusMisconfigured, StatusReportOnly, StatusDeprecated, StatusDisclosure, StatusMissing}
+
+// flaggedHeader is a header that is reported whenever it is sent
+type flaggedHeader struct {
+	Name   string
+	Reason string
+}
+
+// deprecatedHeaders are headers that should no longer be sent, along with
+// the reason each one is reported
+var deprecatedHeaders = []flaggedHeader{
+	{"X-XSS-Protection", "the XSS auditor was removed from browsers and could introduce vulnerabilities, rely on Content-Security-Policy instead"},
+	{"Expect-CT", "Certificate Transparency is enforced by browsers by default, so Expect-CT is obsolete"},
+	{"Public-Key-Pins", "HTTP Public Key Pinning was removed from browsers and risks locking out users"},
+	{"Public-Key-Pins-Report-Only", "HTTP Public Key Pinning was removed from browsers"},
+	{"Feature-Policy", "replaced by Permissions-Policy"},
+}
+
+// disclosureHeaders are headers that leak details about the server stack,
+// which helps attackers pick known exploits
+var disclosureHeaders = []flaggedHeader{
+	{"Server", "reveals the server software"},
+	{"X-Powered-By", "reveals the application framework"},
+	{"X-AspNet-Version", "reveals the ASP.NET version"},
+	{"X-AspNetMvc-Version", "reveals the ASP.NET MVC version"},
+	{"X-Generator", "reveals the software that generated the page"},
+}
+
+// disclosesVersion reports whether a header value contains a version
+// number, which makes the disclosure more useful to attackers
+func disclosesVersion(value string) bool {
+	return strings.ContainsAny(value, "0123456789")
+}
+
+// reportOnlyHeaders maps headers to their report-only variant, which
+// reports policy violations without enforcing the policy
+var reportOnlyHeaders = map[string]string{
+	"Content-Security-Policy":      "Content-Security-Policy-Report-Only",
+	"Cross-Origin-Opener-Policy":   "Cross-Origin-Opener-Policy-Report-Only",
+	"Cross-Origin-Embedder-Policy": "Cross-Origin-Embedder-Policy-Report-Only",
+}
+
+// findings collects the problems found while validating a header value.
+// Issues make the header ineffective and mark it as misconfigured, while
+// warnings flag weaker settings that are still worth reviewing.
+type findings struct {
+	Issues   []string
+	Warnings []string
+}
+
+// issue records a misconfiguration
+func (f *findings) issue(format string, args ...interface{}) {
+	f.Issues = append(f.Issues, fmt.Sprintf(format, args...))
+}
+
+// warn records a weakness that does not make the header ineffective
+func (f *findings) warn(format string, args ...interface{}) {
+	f.Warnings = append(f.Warnings, fmt.Sprintf(format, args...))
+}
+
+// validators maps headers to the function that evaluates their value
+// against known-good rules
+var validators = map[string]func(value string, f *findings){
+	"Content-Security-Policy":      validateCSP,
+	"Strict-Transport-Security":    validateHSTS,
+	"X-Frame-Options":              validateXFrameOptions,
+	"X-Content-Type-Options":       validateXContentTypeOptions,
+	"Referrer-Policy":              validateReferrerPolicy,
+	"Permissions-Policy":           validatePermissionsPolicy,
+	"Cross-Origin-Opener-Policy":   validateCOOP,
+	"Cross-Origin-Embedder-Policy": validateCOEP,
+	"Cross-Origin-Resource-Policy": validateCORP,
+
+	"X-Permitted-Cross-Domain-Policies": validateXPermittedCrossDomainPolicies,
+}
+
+// legacyHeaders maps headers to the legacy header they replace, which is
+// reported when still sent
+var legacyHeaders = map[string]string{
+	"Permissions-Policy": "Feature-Policy",
+}
+
+// firstValueHeaders are headers for which browsers only use the first value
+// when the header is sent more than once
+var firstValueHeaders = map[string]bool{
+	"Strict-Transport-Security": true,
+}
+
+// combineValues returns the value browsers act on for a header sent once or
+// more. Repeated headers combine into a comma-separated list, except for
+// those where only the first value counts.
+func combineValues(name string, values []string, f *findings) string {
+	if len(values) > 1 && firstValueHeaders[name] {
+		f.warn("header is sent %d times and only the first value is used", len(values))
+		return values[0]
+	}
+	return strings.Join(values, ", ")
+}
+
+// validateHeader evaluates the values of a header and returns its status
+// along with the problems found
+func validateHeader(name string, values ...string) (Status, findings) {
+	var f findings
+	value := combineValues(name, values, &f)
+	if validate, ok := validators[name]; ok {
+		validate(strings.TrimSpace(value), &f)
+	}
+	if check, ok := profileChecks[name]; ok {
+		check(strings.TrimSpace(value), &f)
+	}
+	if len(f.Issues) > 0 {
+		return StatusMisconfigured, f
+	}
+	return StatusPresent, f
+}
+
+// validateReportOnly evaluates the report-only variant of a header that is
+// not enforced. The policy's own problems are reported as warnings since
+// they only matter once it is enforced.
+func validateReportOnly(name, reportOnlyName, value string) findings {
+	_, policy := validateHeader(name, value)
+	f := findings{Warnings: append(policy.Issues, policy.Warnings...)}
+	f.issue("only %s is set, so violations are reported but not blocked", reportOnlyName)
+	return f
+}
+
+// validateXFrameOptions checks that X-Frame-Options is DENY or SAMEORIGIN,
+// flagging the deprecated and invalid values still emitted by frameworks
+func validateXFrameOptions(value string, f *findings) {
+	// Proxies may fold repeated headers into a comma-separated list
+	options := make(map[string]bool)
+	for _, option := range strings.Split(value, ",") {
+		option = strings.ToUpper(strings.TrimSpace(option))
+		if option == "" || options[option] {
+			continue
+		}
+		options[option] = true
+
+		switch {
+		case option == "DENY", option == "SAMEORIGIN":
+		case strings.HasPrefix(option, "ALLOW-FROM"):
+			f.issue("ALLOW-FROM is deprecated and ignored by modern browsers, use CSP frame-ancestors instead")
+		case option == "ALLOWALL":
+			f.issue("ALLOWALL is not a valid value and allows framing by any site")
+		default:
+			f.issue("invalid value %q, expected DENY or SAMEORIGIN", option)
+		}
+	}
+	if options["DENY"] && options["SAMEORIGIN"] {
+		f.issue("conflicting values DENY and SAMEORIGIN")
+	}
+}
+
+// validateXContentTypeOptions checks that X-Content-Type-Options is nosniff.
+// Browsers only look at the first comma-separated value, so later values
+// are reported as warnings.
+func validateXContentTypeOptions(value string, f *findings) {
+	values := strings.Split(value, ",")
+	first := strings.TrimSpace(values[0])
+	if !strings.EqualFold(first, "nosniff") {
+		// Catch near misses like "no-sniff", "nosniff;" or quoted values
+		normalized := strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' {
+				return r
+			}
+			return -1
+		}, strings.ToLower(first))
+		if normalized == "nosniff" {
+			f.issue("invalid value %q is ignored by browsers, did you mean nosniff?", first)
+		} else {
+			f.issue("invalid value %q, expected nosniff", first)
+		}
+	}
+	for _, extra := range values[1:] {
+		if extra = strings.TrimSpace(extra); !strings.EqualFold(extra, "nosniff") {
+			f.warn("only the first value is used, ignoring %q", extra)
+		}
+	}
+}
+
+// validateXPermittedCrossDomainPolicies checks the policy for Adobe Flash
+// and PDF cross-domain requests, which should be none unless such clients
+// need cross-domain access
+func validateXPermittedCrossDomainPolicies(value string, f *findings) {
+	switch policy := strings.ToLower(value); policy {
+	case "none":
+	case "master-only", "by-content-type", "by-ftp-filename":
+		f.warn("%s allows cross-domain policy files, use none unless Flash or PDF clients need cross-domain access", policy)
+	case "all":
+		f.issue("all allows cross-domain requests from any policy file on the site")
+	default:
+		f.issue("invalid value %q, expected none, master-only, by-content-type or by-ftp-filename", policy)
+	}
+}
+
+// referrerPolicyTokens are the policy tokens defined by the Referrer Policy
+// specification
+var referrerPolicyTokens = map[string]bool{
+	"no-referrer":                     true,
+	"no-referrer-when-downgrade":      true,
+	"same-origin":                     true,
+	"origin":                          true,
+	"strict-origin":                   true,
+	"origin-when-cross-origin":        true,
+	"strict-origin-when-cross-origin": true,
+	"unsafe-url":                      true,
+}
+
+// validateReferrerPolicy checks the Referrer-Policy tokens and flags weak
+// policies. A comma-separated list is a fallback list where browsers apply
+// the last token they recognize.
+func validateReferrerPolicy(value string, f *findings) {
+	effective := ""
+	for _, token := range strings.Split(value, ",") {
+		token = strings.ToLower(strings.TrimSpace(token))
+		if token == "" {
+			continue
+		}
+		if !referrerPolicyTokens[token] {
+			f.warn("unknown policy %q is ignored", token)
+			continue
+		}
+		effective = token
+	}
+
+	switch effective {
+	case "":
+		f.issue("no valid policy found in %q", value)
+	case "unsafe-url":
+		f.issue("unsafe-url sends the full URL to every origin, including over HTTP")
+	case "no-referrer-when-downgrade":
+		f.warn("no-referrer-when-downgrade sends the full URL to every HTTPS origin")
+	case "origin-when-cross-origin":
+		f.warn("origin-when-cross-origin sends the origin on HTTPS to HTTP requests, prefer strict-origin-when-cross-origin")
+	}
+}
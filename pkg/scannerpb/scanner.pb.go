@@ -0,0 +1,791 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v5.29.3
+// source: scanner.proto
+
+// The gRPC service of gosecurityheaders --grpc, which scans the security
+// headers of URLs on behalf of other services.
+
+package scannerpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ScanStatus_State int32
+
+const (
+	ScanStatus_STATE_UNSPECIFIED ScanStatus_State = 0
+	ScanStatus_STATE_RUNNING     ScanStatus_State = 1
+	ScanStatus_STATE_DONE        ScanStatus_State = 2
+)
+
+// Enum value maps for ScanStatus_State.
+var (
+	ScanStatus_State_name = map[int32]string{
+		0: "STATE_UNSPECIFIED",
+		1: "STATE_RUNNING",
+		2: "STATE_DONE",
+	}
+	ScanStatus_State_value = map[string]int32{
+		"STATE_UNSPECIFIED": 0,
+		"STATE_RUNNING":     1,
+		"STATE_DONE":        2,
+	}
+)
+
+func (x ScanStatus_State) Enum() *ScanStatus_State {
+	p := new(ScanStatus_State)
+	*p = x
+	return p
+}
+
+func (x ScanStatus_State) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ScanStatus_State) Descriptor() protoreflect.EnumDescriptor {
+	return file_scanner_proto_enumTypes[0].Descriptor()
+}
+
+func (ScanStatus_State) Type() protoreflect.EnumType {
+	return &file_scanner_proto_enumTypes[0]
+}
+
+func (x ScanStatus_State) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ScanStatus_State.Descriptor instead.
+func (ScanStatus_State) EnumDescriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{4, 0}
+}
+
+type ScanRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Urls          []string               `protobuf:"bytes,1,rep,name=urls,proto3" json:"urls,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanRequest) Reset() {
+	*x = ScanRequest{}
+	mi := &file_scanner_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRequest) ProtoMessage() {}
+
+func (x *ScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRequest.ProtoReflect.Descriptor instead.
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ScanRequest) GetUrls() []string {
+	if x != nil {
+		return x.Urls
+	}
+	return nil
+}
+
+type ScanResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanResponse) Reset() {
+	*x = ScanResponse{}
+	mi := &file_scanner_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanResponse) ProtoMessage() {}
+
+func (x *ScanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanResponse.ProtoReflect.Descriptor instead.
+func (*ScanResponse) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ScanResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetResultRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetResultRequest) Reset() {
+	*x = GetResultRequest{}
+	mi := &file_scanner_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetResultRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetResultRequest) ProtoMessage() {}
+
+func (x *GetResultRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetResultRequest.ProtoReflect.Descriptor instead.
+func (*GetResultRequest) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetResultRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_scanner_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *WatchRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// ScanStatus is the state of a scan
+type ScanStatus struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	State ScanStatus_State       `protobuf:"varint,2,opt,name=state,proto3,enum=gosecurityheaders.v1.ScanStatus_State" json:"state,omitempty"`
+	// total is the number of URLs of the scan
+	Total         int32        `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`
+	Results       []*Result    `protobuf:"bytes,4,rep,name=results,proto3" json:"results,omitempty"`
+	Errors        []*ScanError `protobuf:"bytes,5,rep,name=errors,proto3" json:"errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanStatus) Reset() {
+	*x = ScanStatus{}
+	mi := &file_scanner_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanStatus) ProtoMessage() {}
+
+func (x *ScanStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanStatus.ProtoReflect.Descriptor instead.
+func (*ScanStatus) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ScanStatus) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ScanStatus) GetState() ScanStatus_State {
+	if x != nil {
+		return x.State
+	}
+	return ScanStatus_STATE_UNSPECIFIED
+}
+
+func (x *ScanStatus) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ScanStatus) GetResults() []*Result {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *ScanStatus) GetErrors() []*ScanError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+// ScanEvent is a URL finishing, with either its result or its error
+type ScanEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*ScanEvent_Result
+	//	*ScanEvent_Error
+	Event         isScanEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanEvent) Reset() {
+	*x = ScanEvent{}
+	mi := &file_scanner_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanEvent) ProtoMessage() {}
+
+func (x *ScanEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanEvent.ProtoReflect.Descriptor instead.
+func (*ScanEvent) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ScanEvent) GetEvent() isScanEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *ScanEvent) GetResult() *Result {
+	if x != nil {
+		if x, ok := x.Event.(*ScanEvent_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+func (x *ScanEvent) GetError() *ScanError {
+	if x != nil {
+		if x, ok := x.Event.(*ScanEvent_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isScanEvent_Event interface {
+	isScanEvent_Event()
+}
+
+type ScanEvent_Result struct {
+	Result *Result `protobuf:"bytes,1,opt,name=result,proto3,oneof"`
+}
+
+type ScanEvent_Error struct {
+	Error *ScanError `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*ScanEvent_Result) isScanEvent_Event() {}
+
+func (*ScanEvent_Error) isScanEvent_Event() {}
+
+// ScanError is a URL that could not be scanned, or was skipped
+type ScanError struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Url     string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	Message string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// skipped is set for URLs left out by --non-2xx or robots.txt
+	Skipped       bool `protobuf:"varint,3,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanError) Reset() {
+	*x = ScanError{}
+	mi := &file_scanner_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanError) ProtoMessage() {}
+
+func (x *ScanError) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanError.ProtoReflect.Descriptor instead.
+func (*ScanError) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ScanError) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *ScanError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ScanError) GetSkipped() bool {
+	if x != nil {
+		return x.Skipped
+	}
+	return false
+}
+
+// Result is the result of a URL, as in the JSON output
+type Result struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	Grade         string                 `protobuf:"bytes,2,opt,name=grade,proto3" json:"grade,omitempty"`
+	Score         int32                  `protobuf:"varint,3,opt,name=score,proto3" json:"score,omitempty"`
+	StatusCode    int32                  `protobuf:"varint,4,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"`
+	Protocol      string                 `protobuf:"bytes,5,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Headers       []*HeaderResult        `protobuf:"bytes,6,rep,name=headers,proto3" json:"headers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Result) Reset() {
+	*x = Result{}
+	mi := &file_scanner_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Result) ProtoMessage() {}
+
+func (x *Result) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Result.ProtoReflect.Descriptor instead.
+func (*Result) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Result) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Result) GetGrade() string {
+	if x != nil {
+		return x.Grade
+	}
+	return ""
+}
+
+func (x *Result) GetScore() int32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *Result) GetStatusCode() int32 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *Result) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *Result) GetHeaders() []*HeaderResult {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+// HeaderResult is the result of a header or of another check
+type HeaderResult struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Name    string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Present bool                   `protobuf:"varint,2,opt,name=present,proto3" json:"present,omitempty"`
+	// status is Present, Misconfigured, Report-Only, Deprecated, Disclosure
+	// or Missing
+	Status        string   `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Value         string   `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	Severity      string   `protobuf:"bytes,5,opt,name=severity,proto3" json:"severity,omitempty"`
+	Issues        []string `protobuf:"bytes,6,rep,name=issues,proto3" json:"issues,omitempty"`
+	Warnings      []string `protobuf:"bytes,7,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	Category      string   `protobuf:"bytes,8,opt,name=category,proto3" json:"category,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeaderResult) Reset() {
+	*x = HeaderResult{}
+	mi := &file_scanner_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeaderResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeaderResult) ProtoMessage() {}
+
+func (x *HeaderResult) ProtoReflect() protoreflect.Message {
+	mi := &file_scanner_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeaderResult.ProtoReflect.Descriptor instead.
+func (*HeaderResult) Descriptor() ([]byte, []int) {
+	return file_scanner_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *HeaderResult) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *HeaderResult) GetPresent() bool {
+	if x != nil {
+		return x.Present
+	}
+	return false
+}
+
+func (x *HeaderResult) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *HeaderResult) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *HeaderResult) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *HeaderResult) GetIssues() []string {
+	if x != nil {
+		return x.Issues
+	}
+	return nil
+}
+
+func (x *HeaderResult) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+func (x *HeaderResult) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+var File_scanner_proto protoreflect.FileDescriptor
+
+const file_scanner_proto_rawDesc = "" +
+	"\n" +
+	"\rscanner.proto\x12\x14gosecurityheaders.v1\"!\n" +
+	"\vScanRequest\x12\x12\n" +
+	"\x04urls\x18\x01 \x03(\tR\x04urls\"\x1e\n" +
+	"\fScanResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\"\n" +
+	"\x10GetResultRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x1e\n" +
+	"\fWatchRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xa4\x02\n" +
+	"\n" +
+	"ScanStatus\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12<\n" +
+	"\x05state\x18\x02 \x01(\x0e2&.gosecurityheaders.v1.ScanStatus.StateR\x05state\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x05R\x05total\x126\n" +
+	"\aresults\x18\x04 \x03(\v2\x1c.gosecurityheaders.v1.ResultR\aresults\x127\n" +
+	"\x06errors\x18\x05 \x03(\v2\x1f.gosecurityheaders.v1.ScanErrorR\x06errors\"A\n" +
+	"\x05State\x12\x15\n" +
+	"\x11STATE_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rSTATE_RUNNING\x10\x01\x12\x0e\n" +
+	"\n" +
+	"STATE_DONE\x10\x02\"\x85\x01\n" +
+	"\tScanEvent\x126\n" +
+	"\x06result\x18\x01 \x01(\v2\x1c.gosecurityheaders.v1.ResultH\x00R\x06result\x127\n" +
+	"\x05error\x18\x02 \x01(\v2\x1f.gosecurityheaders.v1.ScanErrorH\x00R\x05errorB\a\n" +
+	"\x05event\"Q\n" +
+	"\tScanError\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x18\n" +
+	"\askipped\x18\x03 \x01(\bR\askipped\"\xc1\x01\n" +
+	"\x06Result\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x14\n" +
+	"\x05grade\x18\x02 \x01(\tR\x05grade\x12\x14\n" +
+	"\x05score\x18\x03 \x01(\x05R\x05score\x12\x1f\n" +
+	"\vstatus_code\x18\x04 \x01(\x05R\n" +
+	"statusCode\x12\x1a\n" +
+	"\bprotocol\x18\x05 \x01(\tR\bprotocol\x12<\n" +
+	"\aheaders\x18\x06 \x03(\v2\".gosecurityheaders.v1.HeaderResultR\aheaders\"\xd6\x01\n" +
+	"\fHeaderResult\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\apresent\x18\x02 \x01(\bR\apresent\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\tR\x05value\x12\x1a\n" +
+	"\bseverity\x18\x05 \x01(\tR\bseverity\x12\x16\n" +
+	"\x06issues\x18\x06 \x03(\tR\x06issues\x12\x1a\n" +
+	"\bwarnings\x18\a \x03(\tR\bwarnings\x12\x1a\n" +
+	"\bcategory\x18\b \x01(\tR\bcategory2\xff\x01\n" +
+	"\aScanner\x12M\n" +
+	"\x04Scan\x12!.gosecurityheaders.v1.ScanRequest\x1a\".gosecurityheaders.v1.ScanResponse\x12U\n" +
+	"\tGetResult\x12&.gosecurityheaders.v1.GetResultRequest\x1a .gosecurityheaders.v1.ScanStatus\x12N\n" +
+	"\x05Watch\x12\".gosecurityheaders.v1.WatchRequest\x1a\x1f.gosecurityheaders.v1.ScanEvent0\x01B!Z\x1fgosecurityheaders/pkg/scannerpbb\x06proto3"
+
+var (
+	file_scanner_proto_rawDescOnce sync.Once
+	file_scanner_proto_rawDescData []byte
+)
+
+func file_scanner_proto_rawDescGZIP() []byte {
+	file_scanner_proto_rawDescOnce.Do(func() {
+		file_scanner_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_scanner_proto_rawDesc), len(file_scanner_proto_rawDesc)))
+	})
+	return file_scanner_proto_rawDescData
+}
+
+var file_scanner_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_scanner_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_scanner_proto_goTypes = []any{
+	(ScanStatus_State)(0),    // 0: gosecurityheaders.v1.ScanStatus.State
+	(*ScanRequest)(nil),      // 1: gosecurityheaders.v1.ScanRequest
+	(*ScanResponse)(nil),     // 2: gosecurityheaders.v1.ScanResponse
+	(*GetResultRequest)(nil), // 3: gosecurityheaders.v1.GetResultRequest
+	(*WatchRequest)(nil),     // 4: gosecurityheaders.v1.WatchRequest
+	(*ScanStatus)(nil),       // 5: gosecurityheaders.v1.ScanStatus
+	(*ScanEvent)(nil),        // 6: gosecurityheaders.v1.ScanEvent
+	(*ScanError)(nil),        // 7: gosecurityheaders.v1.ScanError
+	(*Result)(nil),           // 8: gosecurityheaders.v1.Result
+	(*HeaderResult)(nil),     // 9: gosecurityheaders.v1.HeaderResult
+}
+var file_scanner_proto_depIdxs = []int32{
+	0, // 0: gosecurityheaders.v1.ScanStatus.state:type_name -> gosecurityheaders.v1.ScanStatus.State
+	8, // 1: gosecurityheaders.v1.ScanStatus.results:type_name -> gosecurityheaders.v1.Result
+	7, // 2: gosecurityheaders.v1.ScanStatus.errors:type_name -> gosecurityheaders.v1.ScanError
+	8, // 3: gosecurityheaders.v1.ScanEvent.result:type_name -> gosecurityheaders.v1.Result
+	7, // 4: gosecurityheaders.v1.ScanEvent.error:type_name -> gosecurityheaders.v1.ScanError
+	9, // 5: gosecurityheaders.v1.Result.headers:type_name -> gosecurityheaders.v1.HeaderResult
+	1, // 6: gosecurityheaders.v1.Scanner.Scan:input_type -> gosecurityheaders.v1.ScanRequest
+	3, // 7: gosecurityheaders.v1.Scanner.GetResult:input_type -> gosecurityheaders.v1.GetResultRequest
+	4, // 8: gosecurityheaders.v1.Scanner.Watch:input_type -> gosecurityheaders.v1.WatchRequest
+	2, // 9: gosecurityheaders.v1.Scanner.Scan:output_type -> gosecurityheaders.v1.ScanResponse
+	5, // 10: gosecurityheaders.v1.Scanner.GetResult:output_type -> gosecurityheaders.v1.ScanStatus
+	6, // 11: gosecurityheaders.v1.Scanner.Watch:output_type -> gosecurityheaders.v1.ScanEvent
+	9, // [9:12] is the sub-list for method output_type
+	6, // [6:9] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_scanner_proto_init() }
+func file_scanner_proto_init() {
+	if File_scanner_proto != nil {
+		return
+	}
+	file_scanner_proto_msgTypes[5].OneofWrappers = []any{
+		(*ScanEvent_Result)(nil),
+		(*ScanEvent_Error)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_scanner_proto_rawDesc), len(file_scanner_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_scanner_proto_goTypes,
+		DependencyIndexes: file_scanner_proto_depIdxs,
+		EnumInfos:         file_scanner_proto_enumTypes,
+		MessageInfos:      file_scanner_proto_msgTypes,
+	}.Build()
+	File_scanner_proto = out.File
+	file_scanner_proto_goTypes = nil
+	file_scanner_proto_depIdxs = nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gosecurityheaders/pkg/scanner"
+)
+
+// prometheusLabel escapes a label value for the Prometheus text format
+func prometheusLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// writePrometheus writes the results as metrics in the Prometheus text
+// exposition format, suitable for the node_exporter textfile collector
+func writePrometheus(w io.Writer, results []scanner.Result) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "# HELP security_header_present Whether the security header is present (1) or missing (0).")
+	fmt.Fprintln(bw, "# TYPE security_header_present gauge")
+	for _, result := range results {
+		for _, header := range result.Headers {
+			value := 0
+			if header.Present {
+				value = 1
+			}
+			fmt.Fprintf(bw, "security_header_present{url=\"%s\",header=\"%s\"} %d\n",
+				prometheusLabel(result.URL), prometheusLabel(header.Name), value)
+		}
+	}
+
+	fmt.Fprintln(bw, "# HELP security_header_misconfigured Whether the security header is present but misconfigured (1) or not (0).")
+	fmt.Fprintln(bw, "# TYPE security_header_misconfigured gauge")
+	for _, result := range results {
+		for _, header := range result.Headers {
+			value := 0
+			if header.Status == scanner.StatusMisconfigured {
+				value = 1
+			}
+			fmt.Fprintf(bw, "security_header_misconfigured{url=\"%s\",header=\"%s\"} %d\n",
+				prometheusLabel(result.URL), prometheusLabel(header.Name), value)
+		}
+	}
+
+	fmt.Fprintln(bw, "# HELP security_header_status Status of each reported header, set to 1 for its current status.")
+	fmt.Fprintln(bw, "# TYPE security_header_status gauge")
+	for _, result := range results {
+		for _, header := range result.Headers {
+			fmt.Fprintf(bw, "security_header_status{url=\"%s\",header=\"%s\",status=\"%s\"} 1\n",
+				prometheusLabel(result.URL), prometheusLabel(header.Name), prometheusLabel(string(header.Status)))
+		}
+	}
+
+	fmt.Fprintln(bw, "# HELP security_headers_missing Number of missing security headers.")
+	fmt.Fprintln(bw, "# TYPE security_headers_missing gauge")
+	for _, result := range results {
+		fmt.Fprintf(bw, "security_headers_missing{url=\"%s\"} %d\n",
+			prometheusLabel(result.URL), len(scanner.MissingHeaders(result.Headers)))
+	}
+
+	fmt.Fprintln(bw, "# HELP security_headers_findings Number of header results with findings of each severity.")
+	fmt.Fprintln(bw, "# TYPE security_headers_findings gauge")
+	for _, result := range results {
+		counts := make(map[scanner.Severity]int)
+		for _, header := range result.Headers {
+			counts[header.Severity]++
+		}
+		for _, severity := range scanner.Severities {
+			fmt.Fprintf(bw, "security_headers_findings{url=\"%s\",severity=\"%s\"} %d\n",
+				prometheusLabel(result.URL), severity, counts[severity])
+		}
+	}
+
+	fmt.Fprintln(bw, "# HELP security_headers_waived Number of findings waived by the ignore file.")
+	fmt.Fprintln(bw, "# TYPE security_headers_waived gauge")
+	for _, result := range results {
+		fmt.Fprintf(bw, "security_headers_waived{url=\"%s\"} %d\n", prometheusLabel(result.URL), len(result.Waived))
+	}
+
+	fmt.Fprintln(bw, "# HELP security_headers_retries Number of failed attempts before the URL could be fetched.")
+	fmt.Fprintln(bw, "# TYPE security_headers_retries gauge")
+	for _, result := range results {
+		fmt.Fprintf(bw, "security_headers_retries{url=\"%s\"} %d\n", prometheusLabel(result.URL), result.Retries)
+	}
+
+	fmt.Fprintln(bw, "# HELP security_headers_status_code HTTP status code of the final response.")
+	fmt.Fprintln(bw, "# TYPE security_headers_status_code gauge")
+	for _, result := range results {
+		fmt.Fprintf(bw, "security_headers_status_code{url=\"%s\"} %d\n", prometheusLabel(result.URL), result.StatusCode)
+	}
+
+	fmt.Fprintln(bw, "# HELP security_headers_redirects Number of redirects followed to reach the final response.")
+	fmt.Fprintln(bw, "# TYPE security_headers_redirects gauge")
+	for _, result := range results {
+		fmt.Fprintf(bw, "security_headers_redirects{url=\"%s\"} %d\n", prometheusLabel(result.URL), len(result.Redirects))
+	}
+
+	fmt.Fprintln(bw, "# HELP security_headers_tls_info Negotiated TLS version and cipher suite, always 1.")
+	fmt.Fprintln(bw, "# TYPE security_headers_tls_info gauge")
+	for _, result := range results {
+		if result.TLS != nil {
+			fmt.Fprintf(bw, "security_headers_tls_info{url=\"%s\",version=\"%s\",cipher_suite=\"%s\"} 1\n", prometheusLabel(result.URL), result.TLS.Version, result.TLS.CipherSuite)
+		}
+	}
+
+	fmt.Fprintln(bw, "# HELP security_headers_certificate_days_left Days until the TLS certificate expires.")
+	fmt.Fprintln(bw, "# TYPE security_headers_certificate_days_left gauge")
+	for _, result := range results {
+		if result.TLS != nil {
+			fmt.Fprintf(bw, "security_headers_certificate_days_left{url=\"%s\"} %d\n", prometheusLabel(result.URL), result.TLS.DaysLeft)
+		}
+	}
+
+	fmt.Fprintln(bw, "# HELP security_headers_ocsp_stapled Whether the server stapled an OCSP response (1) or not (0).")
+	fmt.Fprintln(bw, "# TYPE security_headers_ocsp_stapled gauge")
+	for _, result := range results {
+		if result.TLS != nil {
+			stapled := 0
+			if result.TLS.OCSPStapled {
+				stapled = 1
+			}
+			fmt.Fprintf(bw, "security_headers_ocsp_stapled{url=\"%s\"} %d\n", prometheusLabel(result.URL), stapled)
+		}
+	}
+
+	fmt.Fprintln(bw, "# HELP security_headers_score Security headers score out of 100, labelled with the letter grade.")
+	fmt.Fprintln(bw, "# TYPE security_headers_score gauge")
+	for _, result := range results {
+		fmt.Fprintf(bw, "security_headers_score{url=\"%s\",grade=\"%s\"} %d\n",
+			prometheusLabel(result.URL), prometheusLabel(result.Grade), result.Score)
+	}
+
+	fmt.Fprintln(bw, "# HELP security_headers_last_scan_timestamp_seconds Unix time of the last scan.")
+	fmt.Fprintln(bw, "# TYPE security_headers_last_scan_timestamp_seconds gauge")
+	fmt.Fprintf(bw, "security_headers_last_scan_timestamp_seconds %d\n", time.Now().Unix())
+
+	return bw.Flush()
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"gosecurityheaders/pkg/scanner"
+)
+
+// maxScanRequest bounds the body of POST /scan
+const maxScanRequest = 1 << 20
+
+// restScanRequest is the body of POST /scan
+type restScanRequest struct {
+	URLs []string `json:"urls"`
+}
+
+// restScanResponse is the response of POST /scan
+type restScanResponse struct {
+	ID string `json:"id"`
+}
+
+// restStatus is the response of GET /results/{id}
+type restStatus struct {
+	SchemaVersion string `json:"schema_version"`
+	ID            string `json:"id"`
+	// State is running or done
+	State   string           `json:"state"`
+	Total   int              `json:"total"`
+	Results []scanner.Result `json:"results"`
+	Errors  []restError      `json:"errors"`
+}
+
+// restError is a URL of a scan that could not be scanned, or was skipped
+type restError struct {
+	URL     string `json:"url"`
+	Error   string `json:"error"`
+	Skipped bool   `json:"skipped,omitempty"`
+}
+
+// restHandler returns the REST API of the serve subcommand
+func restHandler(jobs *jobStore) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /scan", func(w http.ResponseWriter, r *http.Request) {
+		var req restScanRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxScanRequest)).Decode(&req); err != nil {
+			writeRESTError(w, http.StatusBadRequest, "invalid request: "+err.Error())
+			return
+		}
+		if len(req.URLs) == 0 {
+			writeRESTError(w, http.StatusBadRequest, "no urls to scan")
+			return
+		}
+		job := jobs.start(req.URLs)
+		w.Header().Set("Location", "/results/"+job.ID)
+		writeREST(w, http.StatusAccepted, restScanResponse{ID: job.ID})
+	})
+	mux.HandleFunc("GET /results/{id}", func(w http.ResponseWriter, r *http.Request) {
+		job := jobs.get(r.PathValue("id"))
+		if job == nil {
+			writeRESTError(w, http.StatusNotFound, "no scan "+r.PathValue("id"))
+			return
+		}
+		events, done, _ := job.snapshot(0)
+		status := restStatus{SchemaVersion: scanner.SchemaVersion, ID: job.ID, State: "running", Total: job.Total, Results: []scanner.Result{}, Errors: []restError{}}
+		if done {
+			status.State = "done"
+		}
+		for _, event := range events {
+			if event.Result != nil {
+				status.Results = append(status.Results, *event.Result)
+			} else {
+				status.Errors = append(status.Errors, restError{URL: event.URL, Error: event.Err, Skipped: event.Skipped})
+			}
+		}
+		writeREST(w, http.StatusOK, status)
+	})
+	return mux
+}
+
+// writeREST writes a JSON response
+func writeREST(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeRESTError writes a JSON error response
+func writeRESTError(w http.ResponseWriter, code int, message string) {
+	writeREST(w, code, map[string]string{"error": message})
+}
+
+// tokenMatches reports whether an Authorization header carries the bearer
+// token, comparing in constant time
+func tokenMatches(authorization, token string) bool {
+	sent, ok := strings.CutPrefix(authorization, "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(sent), []byte(token)) == 1
+}
+
+// requireToken rejects the requests without the bearer token, unless the
+// token is empty
+func requireToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !tokenMatches(r.Header.Get("Authorization"), token) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeRESTError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// warnExposed logs when a server that fetches the URLs its clients send
+// listens beyond the loopback interface without a token, as anyone who can
+// reach it can then make the scanner request hosts of the internal network
+func warnExposed(address, token string) {
+	if token != "" {
+		return
+	}
+	host, _, err := net.SplitHostPort(address)
+	if ip := net.ParseIP(host); err == nil && (host == "localhost" || ip != nil && ip.IsLoopback()) {
+		return
+	}
+	log.Printf("Warning: %s is reachable beyond this host and has no --api-token, so anyone reaching it can make the scanner fetch any URL\n", address)
+}
+
+// serveREST serves the REST API on an address until ctx is done, requiring
+// the bearer token unless it is empty
+func serveREST(ctx context.Context, address, token string, jobs *jobStore) error {
+	warnExposed(address, token)
+	server := &http.Server{Addr: address, Handler: requireToken(token, restHandler(jobs)), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdown)
+	}()
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"gosecurityheaders/pkg/scanner"
+)
+
+// sarifSeverities maps each severity to its SARIF level and the
+// security-severity score used by GitHub code scanning
+var sarifSeverities = map[scanner.Severity]struct{ Level, Score string }{
+	scanner.SeverityCritical: {"error", "9.0"},
+	scanner.SeverityHigh:     {"error", "7.0"},
+	scanner.SeverityMedium:   {"warning", "5.0"},
+	scanner.SeverityInfo:     {"note", "2.0"},
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool              `json:"tool"`
+	Results    []sarifResult          `json:"results"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string               `json:"name"`
+	InformationURI string               `json:"informationUri"`
+	Rules          []sarifReportingRule `json:"rules"`
+}
+
+type sarifReportingRule struct {
+	ID                   string              `json:"id"`
+	Name                 string              `json:"name"`
+	ShortDescription     sarifMessage        `json:"shortDescription"`
+	HelpURI              string              `json:"helpUri,omitempty"`
+	DefaultConfiguration sarifConfiguration  `json:"defaultConfiguration"`
+	Properties           sarifRuleProperties `json:"properties"`
+}
+
+type sarifRuleProperties struct {
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+	Tags             []string `json:"tags,omitempty"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID       string             `json:"ruleId"`
+	RuleIndex    int                `json:"ruleIndex"`
+	Level        string             `json:"level"`
+	Message      sarifMessage       `json:"message"`
+	Locations    []sarifLocation    `json:"locations"`
+	Suppressions []sarifSuppression `json:"suppressions,omitempty"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// sarifDescription returns the description of the check of a header,
+// falling back to a generic one for checks without a description
+func sarifDescription(header string) string {
+	for _, check := range scanner.Registered() {
+		if check.ID() == header && check.Description() != "" {
+			return check.Description()
+		}
+	}
+	return header + " is a required security header"
+}
+
+// sarifHelpURIs maps the results other than headers to the documents
+// describing what they check
+var sarifHelpURIs = map[string]string{
+	"TLS-Protocol":             "https://developer.mozilla.org/en-US/docs/Web/Security/Transport_Layer_Security",
+	"Legacy-TLS":               "https://developer.mozilla.org/en-US/docs/Web/Security/Transport_Layer_Security",
+	"TLS-Certificate":          "https://developer.mozilla.org/en-US/docs/Web/Security/Transport_Layer_Security",
+	"Certificate-Transparency": "https://developer.mozilla.org/en-US/docs/Web/Security/Certificate_Transparency",
+	"OCSP-Stapling":            "https://www.rfc-editor.org/rfc/rfc6066#section-8",
+	"CAA":                      "https://www.rfc-editor.org/rfc/rfc8659",
+	"Mixed-Content":            "https://developer.mozilla.org/en-US/docs/Web/Security/Mixed_content",
+	"Subresource-Integrity":    "https://developer.mozilla.org/en-US/docs/Web/Security/Subresource_Integrity",
+	"Redirect-Chain":           "https://developer.mozilla.org/en-US/docs/Web/HTTP/Redirections",
+	"Status-Code":              "https://developer.mozilla.org/en-US/docs/Web/HTTP/Status",
+	"OPA-Policy":               "",
+}
+
+// sarifHelpURI returns the MDN reference of a header, or the document of a
+// result other than a header. Results without one, such as OPA-Policy or
+// those of unknown categories, have no help URI.
+func sarifHelpURI(header, category string) string {
+	if uri, ok := sarifHelpURIs[header]; ok {
+		return uri
+	}
+	if category != "" {
+		return ""
+	}
+	return "https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/" + header
+}
+
+// sarifBuilder builds up the rules and results of a SARIF run
+type sarifBuilder struct {
+	driver    sarifDriver
+	ruleIndex map[string]int
+	results   []sarifResult
+}
+
+// add records a finding for a header, registering its rule on first use.
+// The kind (e.g. "missing", "misconfigured" or "weak") prefixes the rule ID,
+// and the category of results other than headers tags the rule.
+func (b *sarifBuilder) add(kind, header, category string, severity scanner.Severity, description, url, message string) {
+	level := sarifSeverities[severity].Level
+	if level == "" {
+		level = "note"
+	}
+	id := kind + "-" + strings.ToLower(header)
+	index, ok := b.ruleIndex[id]
+	if !ok {
+		var tags []string
+		if category != "" {
+			tags = []string{category}
+		}
+		index = len(b.driver.Rules)
+		b.ruleIndex[id] = index
+		b.driver.Rules = append(b.driver.Rules, sarifReportingRule{
+			ID:                   id,
+			Name:                 strings.ToUpper(kind[:1]) + kind[1:] + strings.ReplaceAll(header, "-", ""),
+			ShortDescription:     sarifMessage{Text: description},
+			HelpURI:              sarifHelpURI(header, category),
+			DefaultConfiguration: sarifConfiguration{Level: level},
+			Properties:           sarifRuleProperties{SecuritySeverity: sarifSeverities[severity].Score, Tags: tags},
+		})
+	}
+	b.results = append(b.results, sarifResult{
+		RuleID:    id,
+		RuleIndex: index,
+		Level:     level,
+		Message:   sarifMessage{Text: message},
+		Locations: []sarifLocation{{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: url},
+			},
+		}},
+	})
+}
+
+// addHeader records the findings of a header result
+func (b *sarifBuilder) addHeader(url string, header scanner.HeaderResult) {
+	switch header.Status {
+	case scanner.StatusMissing:
+		b.add("missing", header.Name, header.Category, header.Severity, sarifDescription(header.Name), url,
+			url+" is missing the "+header.Name+" header")
+	case scanner.StatusDeprecated:
+		b.add("deprecated", header.Name, header.Category, header.Severity, header.Name+" is deprecated and should be removed", url,
+			url+" sends the deprecated "+header.Name+" header: "+strings.Join(header.Issues, "; "))
+	case scanner.StatusDisclosure:
+		b.add("disclosure", header.Name, header.Category, header.Severity, header.Name+" discloses details about the server stack", url,
+			url+" "+header.Name+": "+strings.Join(header.Issues, "; ")+" ("+header.Value+")")
+	case scanner.StatusReportOnly:
+		b.add("report-only", header.Name, header.Category, header.Severity, header.Name+" must be enforced to be effective", url,
+			url+" does not enforce "+header.Name+": "+strings.Join(header.Issues, "; "))
+	case scanner.StatusMisconfigured:
+		b.add("misconfigured", header.Name, header.Category, header.Severity, header.Name+" must be configured correctly to be effective", url,
+			url+" has a misconfigured "+header.Name+" header: "+strings.Join(header.Issues, "; "))
+	}
+	for _, warning := range header.Warnings {
+		b.add("weak", header.Name, header.Category, scanner.SeverityInfo, header.Name+" uses a weak configuration", url,
+			url+" "+header.Name+": "+warning)
+	}
+}
+
+// writeSARIF writes the missing and misconfigured headers as SARIF 2.1.0
+// findings, with weak configurations reported as notes
+func writeSARIF(w io.Writer, results []scanner.Result) error {
+	b := &sarifBuilder{
+		driver: sarifDriver{
+			Name:           "gosecurityheaders",
+			InformationURI: "https://github.com/an00byss/gosecurityheaders",
+			Rules:          []sarifReportingRule{},
+		},
+		ruleIndex: make(map[string]int),
+		results:   []sarifResult{},
+	}
+
+	for _, result := range results {
+		for _, header := range result.Headers {
+			b.addHeader(result.URL, header)
+		}
+
+		// Waived findings are kept with an external suppression, the way
+		// SARIF records accepted risks
+		for _, waived := range result.Waived {
+			start := len(b.results)
+			b.addHeader(result.URL, waived.HeaderResult)
+			for i := start; i < len(b.results); i++ {
+				b.results[i].Suppressions = []sarifSuppression{{Kind: "external", Justification: waived.Reason}}
+			}
+		}
+	}
+
+	// Grades have no SARIF equivalent, so they go in the run's property bag
+	grades := make(map[string]interface{})
+	for _, result := range results {
+		grades[result.URL] = map[string]interface{}{
+			"grade":            result.Grade,
+			"score":            result.Score,
+			"status_code":      result.StatusCode,
+			"protocol":         result.Protocol,
+			"content_encoding": result.ContentEncoding,
+			"address":          result.Address,
+			"family":           result.Family,
+			"retries":          result.Retries,
+			"redirects":        result.Redirects,
+			"tls":              result.TLS,
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool:       sarifTool{Driver: b.driver},
+			Results:    b.results,
+			Properties: map[string]interface{}{"grades": grades},
+		}},
+	})
+}
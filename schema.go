@@ -0,0 +1,144 @@
+package main
+
+//go:generate go run . schema --output=schema/results.schema.json
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"gosecurityheaders/pkg/scanner"
+)
+
+// jsonSchema is a JSON Schema (draft 2020-12) document or subschema
+type jsonSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Ref         string                 `json:"$ref,omitempty"`
+	Type        string                 `json:"type,omitempty"`
+	Format      string                 `json:"format,omitempty"`
+	Const       string                 `json:"const,omitempty"`
+	Enum        []string               `json:"enum,omitempty"`
+	Items       *jsonSchema            `json:"items,omitempty"`
+	Properties  map[string]*jsonSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	Defs        map[string]*jsonSchema `json:"$defs,omitempty"`
+}
+
+// schemaEnums holds the values of the string types with a fixed set of them
+var schemaEnums = map[reflect.Type][]string{
+	reflect.TypeOf(scanner.Status("")):   enumValues(scanner.Statuses),
+	reflect.TypeOf(scanner.Severity("")): enumValues(scanner.Severities),
+}
+
+// enumValues returns the values of a string type as strings
+func enumValues[T ~string](values []T) []string {
+	names := make([]string, len(values))
+	for i, value := range values {
+		names[i] = string(value)
+	}
+	return names
+}
+
+// resultSchema generates the JSON Schema of the JSON output from the
+// structs that are encoded, so the published schema can't drift from
+// them. The NDJSON lines follow the Result definition.
+func resultSchema() *jsonSchema {
+	root := &jsonSchema{
+		Schema:      "https://json-schema.org/draft/2020-12/schema",
+		Title:       "gosecurityheaders results",
+		Description: "Results of gosecurityheaders --format=json, schema version " + scanner.SchemaVersion + ". Objects may gain properties without a new schema version.",
+		Type:        "object",
+		Properties:  make(map[string]*jsonSchema),
+		Defs:        make(map[string]*jsonSchema),
+	}
+	addProperties(root, reflect.TypeOf(report{}), root.Defs)
+	root.Properties["schema_version"] = &jsonSchema{Type: "string", Const: scanner.SchemaVersion}
+	return root
+}
+
+// schemaFor returns the schema of a type, adding the structs it refers to
+// to defs under their type name
+func schemaFor(t reflect.Type, defs map[string]*jsonSchema) *jsonSchema {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if enum, ok := schemaEnums[t]; ok {
+		return &jsonSchema{Type: "string", Enum: enum}
+	}
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return &jsonSchema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.String:
+		return &jsonSchema{Type: "string"}
+	case t.Kind() == reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case t.Kind() == reflect.Slice:
+		return &jsonSchema{Type: "array", Items: schemaFor(t.Elem(), defs)}
+	case t.Kind() == reflect.Struct:
+		if _, ok := defs[t.Name()]; !ok {
+			object := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema)}
+			defs[t.Name()] = object
+			addProperties(object, t, defs)
+		}
+		return &jsonSchema{Ref: "#/$defs/" + t.Name()}
+	}
+	return &jsonSchema{}
+}
+
+// addProperties adds the encoded fields of a struct to an object schema,
+// inlining embedded structs as encoding/json does. Fields without
+// omitempty are always encoded, so they are required.
+func addProperties(object *jsonSchema, t reflect.Type, defs map[string]*jsonSchema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			addProperties(object, field.Type, defs)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		object.Properties[name] = schemaFor(field.Type, defs)
+		if !strings.Contains(options, "omitempty") {
+			object.Required = append(object.Required, name)
+		}
+	}
+}
+
+// exportSchema writes the JSON Schema of the JSON output to a file, or to
+// stdout without one
+func exportSchema(filePath string) error {
+	if filePath == "" {
+		return writeSchema(os.Stdout)
+	}
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if err := writeSchema(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writeSchema writes the JSON Schema of the JSON output
+func writeSchema(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(resultSchema())
+}
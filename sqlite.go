@@ -0,0 +1,188 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"gosecurityheaders/pkg/scanner"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema creates the tables used to store scan history
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS scans (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	scanned_at TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS results (
+	scan_id INTEGER NOT NULL REFERENCES scans(id),
+	url     TEXT NOT NULL,
+	header  TEXT NOT NULL,
+	present INTEGER NOT NULL,
+	value   TEXT
+);
+CREATE INDEX IF NOT EXISTS results_url ON results (url);
+CREATE TABLE IF NOT EXISTS grades (
+	scan_id INTEGER NOT NULL REFERENCES scans(id),
+	url     TEXT NOT NULL,
+	grade   TEXT NOT NULL,
+	score   INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS waived (
+	scan_id  INTEGER NOT NULL REFERENCES scans(id),
+	url      TEXT NOT NULL,
+	header   TEXT NOT NULL,
+	status   TEXT NOT NULL,
+	severity TEXT,
+	reason   TEXT NOT NULL,
+	expires  TEXT
+);
+CREATE TABLE IF NOT EXISTS redirects (
+	scan_id  INTEGER NOT NULL REFERENCES scans(id),
+	url      TEXT NOT NULL,
+	hop      INTEGER NOT NULL,
+	from_url TEXT NOT NULL,
+	status   INTEGER NOT NULL,
+	location TEXT NOT NULL,
+	upgrade  BOOLEAN NOT NULL,
+	headers  TEXT
+);
+`
+
+// sqliteColumns are the columns added to the tables after their initial
+// schema, in the order they were introduced
+var sqliteColumns = []struct{ table, name, definition string }{
+	{"results", "status", "TEXT"},
+	{"results", "issues", "TEXT"},
+	{"results", "warnings", "TEXT"},
+	{"results", "severity", "TEXT"},
+	{"grades", "status_code", "INTEGER"},
+	{"grades", "content_encoding", "TEXT"},
+	{"grades", "certificate_expires", "TEXT"},
+	{"grades", "tls_version", "TEXT"},
+	{"grades", "cipher_suite", "TEXT"},
+	{"grades", "ocsp_stapled", "INTEGER"},
+}
+
+// migrateSQLite adds any columns missing from databases created by older
+// versions
+func migrateSQLite(db *sql.DB) error {
+	existing := make(map[string]bool)
+	for _, table := range []string{"results", "grades"} {
+		rows, err := db.Query("SELECT name FROM pragma_table_info('" + table + "')")
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				rows.Close()
+				return err
+			}
+			existing[table+"."+name] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+
+	for _, column := range sqliteColumns {
+		if existing[column.table+"."+column.name] {
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE " + column.table + " ADD COLUMN " + column.name + " " + column.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSQLite appends the results to a SQLite database as a new scan
+func writeSQLite(filePath string, results []scanner.Result) error {
+	db, err := sql.Open("sqlite", filePath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return err
+	}
+	if err := migrateSQLite(db); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	scan, err := tx.Exec("INSERT INTO scans (scanned_at) VALUES (?)", time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+	scanID, err := scan.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO results (scan_id, url, header, present, value, status, issues, warnings, severity) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	gradeStmt, err := tx.Prepare("INSERT INTO grades (scan_id, url, grade, score, status_code, content_encoding, certificate_expires, tls_version, cipher_suite, ocsp_stapled) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer gradeStmt.Close()
+
+	waivedStmt, err := tx.Prepare("INSERT INTO waived (scan_id, url, header, status, severity, reason, expires) VALUES (?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer waivedStmt.Close()
+
+	redirectStmt, err := tx.Prepare("INSERT INTO redirects (scan_id, url, hop, from_url, status, location, upgrade, headers) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer redirectStmt.Close()
+
+	for _, result := range results {
+		var certificateExpires, tlsVersion, cipherSuite, ocspStapled interface{}
+		if result.TLS != nil {
+			certificateExpires = result.TLS.Expires.Format(time.RFC3339)
+			tlsVersion, cipherSuite, ocspStapled = result.TLS.Version, result.TLS.CipherSuite, result.TLS.OCSPStapled
+		}
+		if _, err := gradeStmt.Exec(scanID, result.URL, result.Grade, result.Score, result.StatusCode, result.ContentEncoding, certificateExpires, tlsVersion, cipherSuite, ocspStapled); err != nil {
+			return err
+		}
+		for _, header := range result.Headers {
+			_, err := stmt.Exec(scanID, result.URL, header.Name, header.Present, header.Value,
+				string(header.Status), strings.Join(header.Issues, "\n"), strings.Join(header.Warnings, "\n"), string(header.Severity))
+			if err != nil {
+				return err
+			}
+		}
+		for _, waived := range result.Waived {
+			_, err := waivedStmt.Exec(scanID, result.URL, waived.Name, string(waived.Status), string(waived.Severity), waived.Reason, waived.Expires)
+			if err != nil {
+				return err
+			}
+		}
+		for i, hop := range result.Redirects {
+			_, err := redirectStmt.Exec(scanID, result.URL, i+1, hop.URL, hop.Status, hop.Location, hop.Upgrade, strings.Join(hop.Headers, ", "))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
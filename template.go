@@ -0,0 +1,32 @@
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gosecurityheaders/pkg/scanner"
+)
+
+// templateFuncs are the helper functions available to custom templates
+var templateFuncs = template.FuncMap{
+	"join":          strings.Join,
+	"lower":         strings.ToLower,
+	"upper":         strings.ToUpper,
+	"missing":       scanner.MissingHeaders,
+	"misconfigured": scanner.MisconfiguredHeaders,
+}
+
+// templateWriter parses a Go text/template file and returns a writer that
+// renders the results with it. The template is executed with the same top
+// level structure as the JSON output, so {{range .Results}} iterates URLs.
+func templateWriter(filePath string) (func(io.Writer, []scanner.Result) error, error) {
+	tmpl, err := template.New(filepath.Base(filePath)).Funcs(templateFuncs).ParseFiles(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return func(w io.Writer, results []scanner.Result) error {
+		return tmpl.Execute(w, newReport(results))
+	}, nil
+}
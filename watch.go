@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"gosecurityheaders/pkg/scanner"
+)
+
+// headerWatch prints how the headers of the URLs change between the rounds
+// of --watch, after displaying their first results in full
+type headerWatch struct {
+	previous map[string]scanner.Result
+}
+
+func newHeaderWatch() *headerWatch {
+	return &headerWatch{previous: make(map[string]scanner.Result)}
+}
+
+// report compares the results of a round with those of the previous one,
+// printing the URLs whose headers appeared, disappeared or changed value
+func (w *headerWatch) report(results []scanner.Result) error {
+	for _, result := range results {
+		previous, seen := w.previous[result.URL]
+		w.previous[result.URL] = result
+		if !seen {
+			displayResults(result)
+			continue
+		}
+		changes := headerChanges(previous, result)
+		if len(changes) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s: %s changed, grade %s (%d/100)\n", time.Now().Format(time.RFC3339), result.URL, gradeColor(result.Grade)(result.Grade), result.Score)
+		for _, change := range changes {
+			fmt.Printf("  %s\n", change)
+		}
+	}
+	return nil
+}
+
+// headerChanges describes the headers sent with one result but not the
+// other, and those sent with both whose values differ
+func headerChanges(old, new scanner.Result) []string {
+	before, after := sentHeaders(old), sentHeaders(new)
+	var changes []string
+	compared := make(map[string]bool)
+	for _, result := range append(append([]scanner.HeaderResult(nil), new.Headers...), old.Headers...) {
+		if compared[result.Name] {
+			continue
+		}
+		compared[result.Name] = true
+		oldValue, wasSent := before[result.Name]
+		value, sent := after[result.Name]
+		switch {
+		case sent && !wasSent:
+			changes = append(changes, fmt.Sprintf("%s appeared: %s", result.Name, value))
+		case wasSent && !sent:
+			changes = append(changes, fmt.Sprintf("%s disappeared (was %s)", result.Name, oldValue))
+		case sent && oldValue != value:
+			changes = append(changes, fmt.Sprintf("%s changed: %s -> %s", result.Name, oldValue, value))
+		}
+	}
+	return changes
+}
+
+// sentHeaders maps the names of the headers sent with a result to their
+// values, leaving out the results of other checks such as the TLS ones
+func sentHeaders(r scanner.Result) map[string]string {
+	headers := make(map[string]string)
+	for _, result := range r.Headers {
+		if result.Present && result.Category == "" {
+			headers[result.Name] = result.Value
+		}
+	}
+	return headers
+}